@@ -0,0 +1,237 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/robfig/cron/v3"
+)
+
+// App 持有一次运行所需的全部依赖和运行时状态，由 main 显式构建
+type App struct {
+	cfg      *Config
+	state    *StateStore
+	notifier Notifier
+	telegram *TelegramClient
+	history  *PointsHistory
+	metrics  *Metrics
+	pipeline *Pipeline
+	// newDriver 创建论坛驱动，测试时可替换
+	newDriver func() (SiteDriver, error)
+	// browsers 常驻浏览器，未启用时为 nil，每次执行启动新的 Chrome
+	browsers *BrowserManager
+	// chrome 记录本程序启动的 Chrome 进程，清理时只处理这些进程
+	chrome *ChromeTracker
+	// session 保存登录 cookies，两种驱动共用
+	session *SessionStore
+
+	mu      sync.Mutex
+	running bool
+	// paused 暂停后定时任务触发时直接跳过
+	paused    bool
+	scheduler *cron.Cron
+	// taskEntryID 签到任务在调度器中的 ID，用于区分积分报告等其他定时任务
+	taskEntryID cron.EntryID
+	retryTimer  *time.Timer
+	// retryAt 为待执行重试的时间，零值表示没有待执行的重试
+	retryAt time.Time
+
+	monitorStop chan struct{}
+	httpServer  *http.Server
+
+	commandBotMu sync.Mutex
+	commandBot   *tgbotapi.BotAPI
+}
+
+// NewApp 根据配置创建应用，只加载状态和构建依赖，不启动任何后台任务
+func NewApp(cfg *Config) *App {
+	app := &App{
+		cfg:      cfg,
+		metrics:  newMetrics(),
+		history:  NewPointsHistory(cfg.Storage.PointsHistoryFile),
+		pipeline: newTaskPipeline(),
+		chrome:   NewChromeTracker(cfg.Browser.UserDataDir),
+		session:  NewSessionStore(cookiesFile),
+	}
+
+	// 加载持久化状态
+	store, err := NewStateStore(cfg.Storage.StateFile)
+	if err != nil {
+		log.Printf("加载状态文件失败，将使用空状态: %v", err)
+	}
+	app.state = store
+
+	// 创建共享的 Telegram 客户端
+	if cfg.Telegram.BotToken != "" {
+		app.telegram = NewTelegramClient(cfg.Telegram.BotToken, cfg.Telegram.OutboxFile)
+	}
+
+	// 构建通知方式
+	app.notifier = buildNotifiers(cfg, app.telegram)
+
+	if cfg.Browser.Persistent && cfg.Site.Driver == DriverBrowser {
+		app.browsers = NewBrowserManager(cfg, app.metrics, app.chrome)
+	}
+	app.newDriver = func() (SiteDriver, error) {
+		if cfg.Site.Driver == DriverHTTP {
+			return NewHTTPDriver(cfg, app.session), nil
+		}
+		browser, err := app.newBrowser()
+		if err != nil {
+			return nil, err
+		}
+		return NewPHPWindDriver(cfg, browser, app.session), nil
+	}
+	app.metrics.RegisterSteps(app.pipeline.Steps)
+	return app
+}
+
+// Start 启动 Chrome 进程监控、调度器以及按配置启用的命令监听和 HTTP 接口
+func (a *App) Start() {
+	// 输出持久化状态，便于确认重启前的进度
+	if state := a.state.Snapshot(); state.CheckInSuccess {
+		log.Printf("状态文件显示今天(%s)已签到成功", state.Date)
+	}
+
+	// 统计本程序的 Chrome 进程并清理残留的 Chrome，启用常驻浏览器时还通过 CDP 检查浏览器状态
+	a.monitorStop = make(chan struct{})
+	if a.cfg.Site.Driver == DriverBrowser {
+		go a.chrome.Monitor(a.monitorStop, a.metrics)
+	}
+	if a.browsers != nil {
+		go a.browsers.Monitor(a.monitorStop)
+	}
+
+	// 启动调度器
+	a.startScheduler()
+
+	// 启动 Telegram 命令监听
+	if a.cfg.Telegram.Commands {
+		go a.listenTelegramCommands()
+	}
+
+	// 启动 HTTP 接口
+	if a.cfg.HTTP.Addr != "" {
+		a.startHTTPServer(a.cfg.HTTP.Addr)
+	}
+
+	// 如果配置了立即执行任务，则立即执行一次
+	if a.cfg.Schedule.RunOnStart {
+		go a.executeTask()
+	}
+}
+
+// Shutdown 停止所有后台任务并清理 Chrome 进程
+func (a *App) Shutdown() {
+	// 停止监控
+	if a.monitorStop != nil {
+		close(a.monitorStop)
+	}
+
+	// 停止 HTTP 接口
+	a.stopHTTPServer()
+
+	// 停止 Telegram 命令监听，并等待队列中的消息发送完毕
+	a.stopTelegramCommands()
+	a.Close()
+
+	// 停止调度器
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
+
+	// 停止重试计时器
+	a.mu.Lock()
+	if a.retryTimer != nil {
+		a.retryTimer.Stop()
+	}
+	a.mu.Unlock()
+
+	// 关闭常驻浏览器并清理本程序启动的Chrome进程
+	if a.browsers != nil {
+		a.browsers.Close()
+	}
+	a.chrome.KillAll()
+}
+
+// Close 等待队列中的通知发送完毕，一次性命令退出前也需要调用
+func (a *App) Close() {
+	if a.telegram != nil {
+		a.telegram.Close()
+	}
+}
+
+// newBrowser 启用常驻浏览器时打开新的标签页，否则启动新的 Chrome
+func (a *App) newBrowser() (*Browser, error) {
+	if a.browsers != nil {
+		return a.browsers.NewTab()
+	}
+	return NewBrowser(a.cfg, a.metrics, a.chrome)
+}
+
+// startScheduler 启动定时调度器
+func (a *App) startScheduler() {
+	a.scheduler = cron.New(cron.WithSeconds())
+
+	// 添加定时任务
+	entryID, err := a.scheduler.AddFunc(a.cfg.Schedule.Cron, a.scheduledTask)
+	if err != nil {
+		log.Fatalf("添加定时任务失败: %v", err)
+	}
+	a.taskEntryID = entryID
+
+	// 添加积分报告任务
+	if spec := a.cfg.Reports.Weekly; spec != "" {
+		if _, err := a.scheduler.AddFunc(spec, func() { a.sendPointsReport(false) }); err != nil {
+			log.Printf("添加积分周报任务失败: %v", err)
+		}
+	}
+	if spec := a.cfg.Reports.Monthly; spec != "" {
+		if _, err := a.scheduler.AddFunc(spec, func() { a.sendPointsReport(true) }); err != nil {
+			log.Printf("添加积分月报任务失败: %v", err)
+		}
+	}
+
+	// 启动调度器
+	a.scheduler.Start()
+}
+
+// scheduledTask 定时任务入口，调度器暂停时跳过
+func (a *App) scheduledTask() {
+	a.mu.Lock()
+	paused := a.paused
+	a.mu.Unlock()
+
+	if paused {
+		log.Println("定时任务已暂停，跳过本次执行")
+		return
+	}
+	a.executeTask()
+}
+
+// pauseScheduler 暂停定时任务，已安排的重试不受影响
+func (a *App) pauseScheduler() {
+	a.mu.Lock()
+	a.paused = true
+	a.mu.Unlock()
+	log.Println("定时任务已暂停")
+}
+
+// resumeScheduler 恢复定时任务
+func (a *App) resumeScheduler() {
+	a.mu.Lock()
+	a.paused = false
+	a.mu.Unlock()
+	log.Println("定时任务已恢复")
+}
+
+// nextScheduledRun 返回下一次定时任务的触发时间
+func (a *App) nextScheduledRun() time.Time {
+	if a.scheduler == nil {
+		return time.Time{}
+	}
+	return a.scheduler.Entry(a.taskEntryID).Next
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// 回帖的内容
+var ReplyContents = []string{
+	"感谢楼主分享好片",
+	"感谢分享！！",
+	"谢谢分享！",
+	"感谢分享感谢分享",
+	"必需支持",
+	"简直太爽了",
+	"感谢分享啊",
+	"封面还不错",
+	"有点意思啊",
+	"封面还不错，支持一波",
+	"真不错啊",
+	"不错不错",
+	"这身材可以呀",
+	"终于等到你",
+	"謝謝辛苦分享",
+	"赏心悦目",
+	"快乐无限~~",
+	"這怎麼受的了啊",
+	"谁也挡不住！",
+	"分享支持。",
+	"这谁顶得住啊",
+	"这是要精J人亡啊!",
+	"饰演很赞",
+	"這系列真有戲",
+	"感谢大佬分享v",
+	"看着不错",
+	"感谢老板分享",
+	"可以看看",
+	"谢谢分享！！！",
+	"真是骚气十足",
+	"给我看硬了！",
+	"这个眼神谁顶得住。",
+	"妙不可言",
+	"看硬了，确实不错。",
+	"这个我是真的喜欢",
+	"如何做到像楼主一样呢",
+	"分享一下技巧楼主",
+	"身材真不错啊",
+	"真是极品啊",
+	"这个眼神谁顶得住。",
+	"妙不可言",
+	"感谢分享这一部资源",
+	"终于来了，等了好久了。",
+	"等这一部等了好久了！",
+	"确实不错。",
+	"真是太好看了",
+}
+
+// randomReplyContent 随机选择回帖内容
+func randomReplyContent() string {
+	return ReplyContents[time.Now().Unix()%int64(len(ReplyContents))]
+}
+
+// Browser 结构体封装了 chromedp 的执行上下文，用于后续多步操作
+type Browser struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cfg     *Config
+	metrics *Metrics
+}
+
+// NewBrowser 连接远程 Chrome 或启动新的 Chrome 并记录到 procs 中，确保上下文可用。m 为 nil 时不记录指标
+func NewBrowser(cfg *Config, m *Metrics, procs *ChromeTracker) (*Browser, error) {
+	// 清理之前运行残留的 Chrome，不影响其他程序的浏览器
+	if cfg.Browser.ForceKillChrome {
+		procs.CleanupOrphans()
+	}
+
+	ctx, cancel, err := startChrome(cfg, procs)
+	if err != nil {
+		return nil, err
+	}
+	return &Browser{
+		ctx:     ctx,
+		cancel:  cancel,
+		cfg:     cfg,
+		metrics: m,
+	}, nil
+}
+
+// startChrome 配置了远程 Chrome 时优先连接远程浏览器，连接失败或未配置时在本地启动 Chrome
+func startChrome(cfg *Config, procs *ChromeTracker) (context.Context, context.CancelFunc, error) {
+	if url := cfg.Browser.RemoteURL; url != "" {
+		ctx, cancel, err := connectRemoteChrome(url)
+		if err == nil {
+			return ctx, cancel, nil
+		}
+		log.Printf("%v，改为在本地启动Chrome", err)
+	}
+	return procs.launch(cfg)
+}
+
+// chromeExecPath 返回配置的 Chrome 路径，未配置时尝试几个常见的路径
+func chromeExecPath(cfg *Config) string {
+	// 从配置中获取Chrome路径
+	chromePath := cfg.Browser.ChromePath
+	if chromePath != "" {
+		log.Printf("使用配置的Chrome路径: %s", chromePath)
+		return chromePath
+	}
+
+	possiblePaths := []string{
+		"/snap/bin/chromium",
+		"chromium",
+		"google-chrome",
+		"chromium-browser",
+		"/usr/bin/chromium",
+		"/usr/bin/chromium-browser",
+		"/usr/bin/google-chrome",
+	}
+	for _, path := range possiblePaths {
+		// 使用 which 命令检查可执行文件是否存在
+		cmd := exec.Command("which", path)
+		if err := cmd.Run(); err == nil {
+			log.Printf("自动检测到Chrome路径: %s", path)
+			return path
+		}
+	}
+
+	log.Println("未找到Chrome可执行文件，请设置CHROME_PATH环境变量")
+	return ""
+}
+
+// allocatorOptions 返回启动 Chrome 的命令行参数
+func allocatorOptions(cfg *Config) []chromedp.ExecAllocatorOption {
+	return append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Flag("headless", cfg.Browser.Headless),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("disable-software-rasterizer", true),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-setuid-sandbox", true),
+		chromedp.Flag("disable-infobars", true),
+		chromedp.Flag("disable-notifications", true),
+		chromedp.Flag("mute-audio", true),
+		chromedp.Flag("ignore-certificate-errors", true),
+		chromedp.Flag("disable-popup-blocking", true),
+		chromedp.Flag("incognito", true),
+		chromedp.Flag("disable-translate", true),
+		chromedp.Flag("disable-sync", true),
+		chromedp.Flag("disable-background-networking", true),
+		chromedp.ExecPath(chromeExecPath(cfg)),
+	)
+}
+
+// Close 关闭浏览器实例
+func (b *Browser) Close() {
+	b.cancel()
+}
+
+// Execute 用于执行一组 chromedp.Action，并设置一个超时
+func (b *Browser) Execute(actions ...chromedp.Action) error {
+	ctx, cancel := context.WithTimeout(b.ctx, 60*time.Second)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		if b.metrics != nil {
+			b.metrics.ObserveExecute(time.Since(start))
+		}
+	}()
+	return chromedp.Run(ctx, actions...)
+}
+
+// NavigateTo 导航到指定页面
+func (b *Browser) NavigateTo(url string) error {
+	return b.Execute(chromedp.Navigate(url))
+}
+
+// WaitForElement 等待页面中指定的元素可见
+func (b *Browser) WaitForElement(selector string) error {
+	return b.Execute(chromedp.WaitVisible(selector))
+}
+
+// GetHTML 获取指定 js 路径对应的HTML内容
+func (b *Browser) GetHTML(sel string) (string, error) {
+	var html string
+	err := b.Execute(chromedp.OuterHTML(sel, &html, chromedp.ByQuery))
+	return html, err
+}
+
+// Inspect 检查元素是否存在且可见，不会等待元素出现。opts 为空时按 chromedp 默认方式查找，支持 XPath
+func (b *Browser) Inspect(selector string, opts ...chromedp.QueryOption) (found bool, visible bool, err error) {
+	var nodes []*cdp.Node
+	err = b.Execute(
+		chromedp.Nodes(selector, &nodes, append(opts, chromedp.AtLeast(0))...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return nil
+			}
+			found = true
+			// 没有盒模型说明元素未渲染，视为不可见
+			box, err := dom.GetBoxModel().WithNodeID(nodes[0].NodeID).Do(ctx)
+			if err == nil {
+				visible = box.Width > 0 && box.Height > 0
+			}
+			return nil
+		}),
+	)
+	return found, visible, err
+}
+
+// Snapshot 获取当前页面的地址、HTML 和整页截图，浏览器无响应时最多等待20秒
+func (b *Browser) Snapshot() (PageSnapshot, error) {
+	ctx, cancel := context.WithTimeout(b.ctx, 20*time.Second)
+	defer cancel()
+
+	var snap PageSnapshot
+	err := chromedp.Run(ctx,
+		chromedp.Location(&snap.URL),
+		chromedp.OuterHTML("html", &snap.HTML, chromedp.ByQuery),
+		chromedp.FullScreenshot(&snap.Screenshot, 90),
+	)
+	return snap, err
+}
+
+// Click 模拟点击操作
+func (b *Browser) Click(selector string) error {
+	return b.Execute(chromedp.Click(selector, chromedp.ByQuery))
+}
+
+// Input 模拟输入文本
+func (b *Browser) Input(selector, text string) error {
+	return b.Execute(
+		chromedp.WaitVisible(selector, chromedp.ByQuery),
+		chromedp.SendKeys(selector, text, chromedp.ByQuery),
+	)
+}
+
+// Cookies 读取当前页面的 cookies，登录后保存到会话文件中
+func (b *Browser) Cookies() ([]*network.Cookie, error) {
+	var cookies []*network.Cookie
+	err := b.Execute(chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().Do(ctx)
+		return err
+	}))
+	return cookies, err
+}
+
+// ClearCookies 清除浏览器中的 cookies，不影响保存的会话文件
+func (b *Browser) ClearCookies() error {
+	return b.Execute(network.ClearBrowserCookies())
+}
+
+// SetCookies 设置保存的 cookies 并刷新当前页面
+func (b *Browser) SetCookies(cookies []*network.Cookie) error {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		param := &network.CookieParam{
+			Name:         c.Name,
+			Value:        c.Value,
+			Domain:       c.Domain,
+			Path:         c.Path,
+			Secure:       c.Secure,
+			HTTPOnly:     c.HTTPOnly,
+			SameSite:     c.SameSite,
+			Priority:     c.Priority,
+			SourceScheme: c.SourceScheme,
+			SourcePort:   c.SourcePort,
+		}
+		// 会话 cookie 不设置过期时间
+		if !c.Session && c.Expires > 0 {
+			expires := cdp.TimeSinceEpoch(time.UnixMilli(int64(c.Expires * 1000)))
+			param.Expires = &expires
+		}
+		params = append(params, param)
+	}
+
+	var text string
+	return b.Execute(
+		network.SetCookies(params),
+		chromedp.Reload(),
+		chromedp.Title(&text),
+	)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+)
+
+// 常驻浏览器的健康检查参数
+const (
+	browserHealthTimeout  = 10 * time.Second
+	browserHealthInterval = 5 * time.Minute
+)
+
+// BrowserManager 维护一个常驻的 Chrome，每次执行在新的标签页中进行。
+// 每个标签页使用独立的浏览器上下文，cookies 不会在两次执行之间共享
+type BrowserManager struct {
+	cfg     *Config
+	metrics *Metrics
+	procs   *ChromeTracker
+
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewBrowserManager 创建管理器，第一次打开标签页时才启动 Chrome
+func NewBrowserManager(cfg *Config, m *Metrics, procs *ChromeTracker) *BrowserManager {
+	return &BrowserManager{cfg: cfg, metrics: m, procs: procs}
+}
+
+// NewTab 在常驻的 Chrome 中打开新的标签页，返回的 Browser 关闭时只关闭该标签页
+func (bm *BrowserManager) NewTab() (*Browser, error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if err := bm.ensureLocked(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := chromedp.NewContext(bm.ctx, chromedp.WithNewBrowserContext())
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		// 打开标签页失败通常说明浏览器已无响应，下次使用时重启
+		bm.stopLocked()
+		return nil, fmt.Errorf("打开标签页失败: %w", err)
+	}
+	return &Browser{
+		ctx:     ctx,
+		cancel:  cancel,
+		cfg:     bm.cfg,
+		metrics: bm.metrics,
+	}, nil
+}
+
+// ensureLocked 确保浏览器已启动且能通过 CDP 响应，否则重新启动
+func (bm *BrowserManager) ensureLocked() error {
+	if bm.ctx != nil {
+		err := bm.checkLocked()
+		if err == nil {
+			return nil
+		}
+		log.Printf("常驻浏览器健康检查失败，重新启动: %v", err)
+		bm.stopLocked()
+	}
+	return bm.startLocked()
+}
+
+// startLocked 启动 Chrome，第一个上下文只用于保持浏览器运行和健康检查
+func (bm *BrowserManager) startLocked() error {
+	// 清理之前运行残留的 Chrome，只在启动常驻浏览器时执行
+	if bm.cfg.Browser.ForceKillChrome {
+		bm.procs.CleanupOrphans()
+	}
+
+	ctx, cancel, err := startChrome(bm.cfg, bm.procs)
+	if err != nil {
+		return fmt.Errorf("启动常驻浏览器失败: %w", err)
+	}
+	bm.ctx = ctx
+	bm.cancel = cancel
+	log.Println("常驻浏览器已启动")
+	return nil
+}
+
+// stopLocked 关闭 Chrome
+func (bm *BrowserManager) stopLocked() {
+	if bm.cancel != nil {
+		bm.cancel()
+	}
+	bm.ctx, bm.cancel = nil, nil
+}
+
+// checkLocked 通过 CDP 的 Browser.getVersion 检查浏览器是否仍在响应
+func (bm *BrowserManager) checkLocked() error {
+	if err := bm.ctx.Err(); err != nil {
+		return errors.New("浏览器已退出")
+	}
+	c := chromedp.FromContext(bm.ctx)
+	if c == nil || c.Browser == nil {
+		return errors.New("浏览器未启动")
+	}
+	ctx, cancel := context.WithTimeout(bm.ctx, browserHealthTimeout)
+	defer cancel()
+	_, _, _, _, _, err := browser.GetVersion().Do(cdp.WithExecutor(ctx, c.Browser))
+	return err
+}
+
+// Monitor 定期检查常驻浏览器，崩溃或无响应时重新启动，直到 stop 被关闭
+func (bm *BrowserManager) Monitor(stop chan struct{}) {
+	log.Println("开始监控常驻浏览器...")
+	ticker := time.NewTicker(browserHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			bm.mu.Lock()
+			// 还没有启动过的浏览器等到第一次使用时再启动
+			if bm.ctx != nil {
+				if err := bm.ensureLocked(); err != nil {
+					log.Printf("重新启动常驻浏览器失败: %v", err)
+				}
+			}
+			bm.mu.Unlock()
+		case <-stop:
+			log.Println("常驻浏览器监控已停止")
+			return
+		}
+	}
+}
+
+// Close 关闭常驻浏览器
+func (bm *BrowserManager) Close() {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.stopLocked()
+}
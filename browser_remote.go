@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// 连接远程 Chrome 的重试参数
+const (
+	remoteConnectAttempts = 3
+	remoteConnectTimeout  = 30 * time.Second
+	remoteBaseBackoff     = 2 * time.Second
+)
+
+// connectRemoteChrome 连接已在运行的 Chrome（如 chromedp/headless-shell 容器），失败时指数退避重试。
+// 每次连接使用独立的浏览器上下文，cookies 不会与其他使用该浏览器的程序共享；
+// 取消时只关闭打开的标签页，不会关闭远程浏览器
+func connectRemoteChrome(url string) (context.Context, context.CancelFunc, error) {
+	backoff := remoteBaseBackoff
+	var lastErr error
+	for attempt := 1; attempt <= remoteConnectAttempts; attempt++ {
+		ctx, cancel, err := dialRemoteChrome(url)
+		if err == nil {
+			log.Printf("已连接远程Chrome: %s", url)
+			return ctx, cancel, nil
+		}
+		lastErr = err
+		if attempt == remoteConnectAttempts {
+			break
+		}
+		log.Printf("连接远程Chrome失败(%d/%d): %v，%v 后重试", attempt, remoteConnectAttempts, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return nil, nil, fmt.Errorf("连接远程Chrome %s 失败: %w", url, lastErr)
+}
+
+// dialRemoteChrome 连接一次远程 Chrome 并打开新的标签页
+func dialRemoteChrome(url string) (context.Context, context.CancelFunc, error) {
+	allocCtx, cancelAlloc := chromedp.NewRemoteAllocator(context.Background(), url)
+	ctx, cancelCtx := chromedp.NewContext(allocCtx, chromedp.WithNewBrowserContext(), chromedp.WithLogf(log.Printf))
+	cancel := func() {
+		cancelCtx()
+		cancelAlloc()
+	}
+
+	// 连接不会自行超时，超时后取消上下文使 Run 返回
+	timer := time.AfterFunc(remoteConnectTimeout, cancel)
+	err := chromedp.Run(ctx)
+	if !timer.Stop() {
+		err = fmt.Errorf("%v 内未能连接", remoteConnectTimeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return ctx, cancel, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrSessionExpired 论坛提示未登录，需要清除 cookies 重新登录，同一会话内重试没有意义
+var ErrSessionExpired = errors.New("登录已失效")
+
+// ErrReplyRequired 论坛要求先回帖才能签到，需要重新回帖后再签到
+var ErrReplyRequired = errors.New("论坛要求先回帖")
+
+// CheckInStatus 签到结果的分类
+type CheckInStatus int
+
+const (
+	CheckInUnknown CheckInStatus = iota
+	CheckInSigned
+	CheckInAlreadySigned
+	CheckInNeedsReply
+	CheckInNotLoggedIn
+)
+
+// String 返回分类名称，记录在状态文件中
+func (s CheckInStatus) String() string {
+	switch s {
+	case CheckInSigned:
+		return "signed"
+	case CheckInAlreadySigned:
+		return "already_signed"
+	case CheckInNeedsReply:
+		return "needs_reply"
+	case CheckInNotLoggedIn:
+		return "not_logged_in"
+	default:
+		return "unknown"
+	}
+}
+
+// CheckInResult 分类后的签到结果
+type CheckInResult struct {
+	Status CheckInStatus
+	// Reward 奖励的积分项名称，如金币；没有识别到奖励时为空
+	Reward       string
+	RewardAmount float64
+	// Message 论坛的原始提示文本
+	Message string
+}
+
+// Summary 返回用于通知的简短描述
+func (r CheckInResult) Summary() string {
+	switch r.Status {
+	case CheckInSigned:
+		if r.Reward != "" {
+			return fmt.Sprintf("签到成功，获得 %s %s", r.Reward, strconv.FormatFloat(r.RewardAmount, 'f', -1, 64))
+		}
+		return "签到成功"
+	case CheckInAlreadySigned:
+		return "今天已签到"
+	case CheckInNeedsReply:
+		return "需要先回帖才能签到"
+	case CheckInNotLoggedIn:
+		return "未登录"
+	default:
+		return "无法识别的签到结果"
+	}
+}
+
+// 各类签到结果在提示文本中的关键字，简体和繁体都要识别
+var (
+	notLoggedInKeywords = []string{
+		"没有登录", "沒有登錄", "未登录", "未登錄", "请先登录", "請先登錄", "请登录", "請登錄",
+	}
+	alreadyCheckedInKeywords = []string{
+		"已经签到", "已簽到", "已經簽到", "已签到", "签到过", "簽到過",
+	}
+	needsReplyKeywords = []string{
+		"先回帖", "回帖后", "回帖後", "需要回帖", "先回复", "回复后", "先回覆", "回覆後",
+	}
+	signedKeywords = []string{
+		"签到成功", "簽到成功", "成功签到", "成功簽到",
+	}
+)
+
+// rewardPatterns 匹配“金币 5”“金币：5”“+5 金币”“5枚金币”等奖励写法
+var rewardPatterns = func() []*regexp.Regexp {
+	var names []string
+	for _, item := range pointItems {
+		names = append(names, regexp.QuoteMeta(item.Label))
+		for _, alias := range item.Aliases {
+			names = append(names, regexp.QuoteMeta(alias))
+		}
+	}
+	name := "(" + strings.Join(names, "|") + ")"
+	number := `\+?(\d+(?:\.\d+)?)`
+	return []*regexp.Regexp{
+		regexp.MustCompile(name + `\s*[:：+＋]?\s*` + number),
+		regexp.MustCompile(number + `\s*(?:枚|个|個|点|點)?\s*` + name),
+	}
+}()
+
+// containsAny 判断文本是否包含任一关键字
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAlreadyCheckedIn 判断签到页提示文本是否表示今天已经签到
+func isAlreadyCheckedIn(text string) bool {
+	return containsAny(text, alreadyCheckedInKeywords)
+}
+
+// classifyCheckIn 根据签到页的提示文本判断签到结果，并提取奖励
+func classifyCheckIn(text string) CheckInResult {
+	result := CheckInResult{Message: strings.TrimSpace(text)}
+	switch {
+	case result.Message == "":
+	case containsAny(text, notLoggedInKeywords):
+		result.Status = CheckInNotLoggedIn
+	// 成功提示中可能带有“已签到 N 天”，需先于已签到判断
+	case containsAny(text, signedKeywords):
+		result.Status = CheckInSigned
+		result.Reward, result.RewardAmount = parseReward(text)
+	case isAlreadyCheckedIn(text):
+		result.Status = CheckInAlreadySigned
+	case containsAny(text, needsReplyKeywords):
+		result.Status = CheckInNeedsReply
+	}
+	return result
+}
+
+// parseReward 提取提示文本中的第一项奖励
+func parseReward(text string) (string, float64) {
+	for i, pattern := range rewardPatterns {
+		m := pattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		name, amount := m[1], m[2]
+		if i == 1 {
+			name, amount = m[2], m[1]
+		}
+		label, _ := pointLabel(name)
+		v, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			continue
+		}
+		return label, v
+	}
+	return "", 0
+}
+
+// checkInOutcome 根据签到结果决定后续处理：已签到返回 nil，表示今天无需再签到；
+// 未登录返回包装了 ErrSessionExpired 的错误，需要重新登录；要求先回帖返回包装了 ErrReplyRequired 的错误，
+// 需要重新回帖；其他情况返回错误，稍后重试
+func checkInOutcome(result CheckInResult) (StepOutput, error) {
+	output := StepOutput{
+		"result":  result.Message,
+		"status":  result.Status.String(),
+		"summary": result.Summary(),
+	}
+	if result.Reward != "" {
+		output["reward"] = result.Reward
+		output["reward_amount"] = strconv.FormatFloat(result.RewardAmount, 'f', -1, 64)
+	}
+
+	switch result.Status {
+	case CheckInSigned, CheckInAlreadySigned:
+		return output, nil
+	case CheckInNotLoggedIn:
+		return output, fmt.Errorf("%w: %s", ErrSessionExpired, result.Message)
+	case CheckInNeedsReply:
+		return output, fmt.Errorf("%w: %s", ErrReplyRequired, result.Message)
+	default:
+		return output, fmt.Errorf("无法识别的签到结果: %q", result.Message)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyCheckIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantStatus CheckInStatus
+		wantReward string
+		wantAmount float64
+	}{
+		{name: "签到成功", text: "签到成功，获得 金币 5 枚", wantStatus: CheckInSigned, wantReward: "金币", wantAmount: 5},
+		{name: "数字在前", text: "恭喜你签到成功！获得了 10 枚金幣", wantStatus: CheckInSigned, wantReward: "金币", wantAmount: 10},
+		{name: "带加号的奖励", text: "簽到成功，威望+2", wantStatus: CheckInSigned, wantReward: "威望", wantAmount: 2},
+		{name: "成功但没有奖励", text: "签到成功", wantStatus: CheckInSigned},
+		{name: "成功提示中带有已签到天数", text: "签到成功，您已签到 12 天，获得金币:3", wantStatus: CheckInSigned, wantReward: "金币", wantAmount: 3},
+		{name: "今天已签到", text: "您今天已经签到过了，请明天再来", wantStatus: CheckInAlreadySigned},
+		{name: "繁体已签到", text: "您今天已經簽到過了", wantStatus: CheckInAlreadySigned},
+		{name: "需要先回帖", text: "请先回帖后再来签到", wantStatus: CheckInNeedsReply},
+		{name: "未登录", text: "您还没有登录或注册，暂时不能使用此功能", wantStatus: CheckInNotLoggedIn},
+		{name: "无法识别", text: "请选择您的心情", wantStatus: CheckInUnknown},
+		{name: "空文本", text: "  ", wantStatus: CheckInUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyCheckIn(tt.text)
+			if got.Status != tt.wantStatus || got.Reward != tt.wantReward || got.RewardAmount != tt.wantAmount {
+				t.Errorf("classifyCheckIn(%q) = %+v，期望 %v %s %v", tt.text, got, tt.wantStatus, tt.wantReward, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestCheckInOutcome(t *testing.T) {
+	tests := []struct {
+		status      CheckInStatus
+		wantErr     bool
+		wantExpired bool
+	}{
+		{status: CheckInSigned},
+		{status: CheckInAlreadySigned},
+		{status: CheckInNeedsReply, wantErr: true},
+		{status: CheckInNotLoggedIn, wantErr: true, wantExpired: true},
+		{status: CheckInUnknown, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status.String(), func(t *testing.T) {
+			output, err := checkInOutcome(CheckInResult{Status: tt.status, Message: "提示"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkInOutcome 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrSessionExpired) != tt.wantExpired {
+				t.Errorf("checkInOutcome 错误 = %v，期望需要重新登录 %v", err, tt.wantExpired)
+			}
+			if output["status"] != tt.status.String() || output["result"] != "提示" {
+				t.Errorf("output = %v", output)
+			}
+		})
+	}
+}
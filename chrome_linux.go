@@ -0,0 +1,47 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setParentDeathSignal 本程序意外退出时由内核结束 Chrome
+func setParentDeathSignal(attr *syscall.SysProcAttr) {
+	attr.Pdeathsig = syscall.SIGKILL
+}
+
+// listProcesses 从 /proc 读取所有进程的进程组和命令行，不依赖 ps 等外部命令
+func listProcesses() ([]chromeProcess, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var procs []chromeProcess
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		// 进程可能在读取过程中退出，读取失败时跳过
+		cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		pgid, err := syscall.Getpgid(pid)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, chromeProcess{
+			PID:   pid,
+			Group: pgid,
+			Args:  strings.ReplaceAll(strings.TrimRight(string(cmdline), "\x00"), "\x00", " "),
+		})
+	}
+	return procs, nil
+}
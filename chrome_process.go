@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultChromeUserDataDir 本程序启动的 Chrome 的用户数据目录，每个 Chrome 使用其下独立的 run-* 子目录
+const DefaultChromeUserDataDir = "./state/chrome"
+
+// chromeProcessLimit 本程序的 Chrome 进程数超过该值时清理残留进程
+const chromeProcessLimit = 5
+
+// chromeProcess 系统中的一个进程。Group 在 Unix 上为进程组 ID，在 Windows 上为父进程 ID
+type chromeProcess struct {
+	PID   int
+	Group int
+	Args  string
+}
+
+// ChromeTracker 记录本程序启动的 Chrome 进程。
+// 清理时只处理这些进程及其子进程，以及命令行中带有本程序用户数据目录的残留进程，
+// 不会影响同一台机器上的其他浏览器
+type ChromeTracker struct {
+	baseDir string
+
+	mu sync.Mutex
+	// dirs 为正在使用的用户数据目录到 Chrome 主进程 PID 的映射，启动完成前 PID 为 0
+	dirs map[string]int
+}
+
+// NewChromeTracker 创建进程记录，baseDir 为空时使用默认目录
+func NewChromeTracker(baseDir string) *ChromeTracker {
+	if baseDir == "" {
+		baseDir = DefaultChromeUserDataDir
+	}
+	// Chrome 的命令行中是绝对路径，匹配残留进程时需要一致
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		baseDir = abs
+	}
+	return &ChromeTracker{baseDir: baseDir, dirs: make(map[string]int)}
+}
+
+// marker 本程序启动的 Chrome 命令行中都带有的参数前缀
+func (t *ChromeTracker) marker() string {
+	return "--user-data-dir=" + filepath.Join(t.baseDir, "run-")
+}
+
+// launchOptions 为新的 Chrome 创建独立的用户数据目录，并让 Chrome 在独立的进程组中运行
+func (t *ChromeTracker) launchOptions() (string, []chromedp.ExecAllocatorOption, error) {
+	if err := os.MkdirAll(t.baseDir, 0700); err != nil {
+		return "", nil, fmt.Errorf("创建 Chrome 用户数据目录失败: %w", err)
+	}
+	dir, err := os.MkdirTemp(t.baseDir, "run-")
+	if err != nil {
+		return "", nil, fmt.Errorf("创建 Chrome 用户数据目录失败: %w", err)
+	}
+	t.mu.Lock()
+	t.dirs[dir] = 0
+	t.mu.Unlock()
+	return dir, []chromedp.ExecAllocatorOption{
+		chromedp.UserDataDir(dir),
+		chromedp.ModifyCmdFunc(setChromeProcAttr),
+	}, nil
+}
+
+// launch 启动 Chrome 并记录其进程，返回的取消函数会结束整个进程树并删除用户数据目录
+func (t *ChromeTracker) launch(cfg *Config) (context.Context, context.CancelFunc, error) {
+	dir, opts, err := t.launchOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), append(allocatorOptions(cfg), opts...)...)
+	ctx, cancelCtx := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	// 启动浏览器（空任务），确保 ctx 正常启动
+	if err := chromedp.Run(ctx); err != nil {
+		cancelCtx()
+		cancelAlloc()
+		t.release(0, dir)
+		return nil, nil, err
+	}
+
+	pid := 0
+	if c := chromedp.FromContext(ctx); c != nil && c.Browser != nil && c.Browser.Process() != nil {
+		pid = c.Browser.Process().Pid
+	}
+	t.mu.Lock()
+	t.dirs[dir] = pid
+	t.mu.Unlock()
+	log.Printf("Chrome 已启动，PID: %d，用户数据目录: %s", pid, dir)
+
+	return ctx, func() {
+		cancelCtx()
+		cancelAlloc()
+		t.release(pid, dir)
+	}, nil
+}
+
+// release 结束 Chrome 主进程所在的进程树并删除其用户数据目录
+func (t *ChromeTracker) release(pid int, dir string) {
+	t.mu.Lock()
+	delete(t.dirs, dir)
+	t.mu.Unlock()
+
+	if pid > 0 {
+		if err := killChromeTree(pid); err != nil {
+			log.Printf("终止Chrome进程(%d)失败: %v", pid, err)
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("删除Chrome用户数据目录失败: %v", err)
+	}
+}
+
+// inUse 返回正在使用的用户数据目录
+func (t *ChromeTracker) inUse() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dirs := make(map[string]int, len(t.dirs))
+	for dir, pid := range t.dirs {
+		dirs[dir] = pid
+	}
+	return dirs
+}
+
+// usesDir 判断命令行是否使用了 dirs 中的某个用户数据目录
+func usesDir(args string, dirs map[string]int) bool {
+	for dir := range dirs {
+		if strings.Contains(args, "--user-data-dir="+dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownProcesses 从进程列表中筛选出本程序的 Chrome 进程树，orphans 为其中不属于已记录进程的 Chrome 主进程
+func (t *ChromeTracker) ownProcesses(procs []chromeProcess) (own []chromeProcess, orphans []int) {
+	dirs := t.inUse()
+	roots := make(map[int]bool)
+	for _, pid := range dirs {
+		if pid > 0 {
+			roots[pid] = true
+		}
+	}
+	marker := t.marker()
+	for _, p := range procs {
+		// 子进程的命令行中带有 --type=，只有主进程才作为进程树的根
+		if !strings.Contains(p.Args, marker) || strings.Contains(p.Args, "--type=") || roots[p.PID] {
+			continue
+		}
+		roots[p.PID] = true
+		if !usesDir(p.Args, dirs) {
+			orphans = append(orphans, p.PID)
+		}
+	}
+	for _, p := range procs {
+		if roots[p.PID] || roots[p.Group] || strings.Contains(p.Args, marker) {
+			own = append(own, p)
+		}
+	}
+	return own, orphans
+}
+
+// CleanupOrphans 结束之前运行残留的 Chrome 进程树，并删除不再使用的用户数据目录
+func (t *ChromeTracker) CleanupOrphans() {
+	procs, err := listProcesses()
+	if err != nil {
+		log.Printf("读取进程列表失败: %v", err)
+	} else {
+		_, orphans := t.ownProcesses(procs)
+		for _, pid := range orphans {
+			log.Printf("终止残留的Chrome进程: %d", pid)
+			if err := killChromeTree(pid); err != nil {
+				log.Printf("终止Chrome进程(%d)失败: %v", pid, err)
+			}
+		}
+	}
+
+	inUse := t.inUse()
+	dirs, _ := filepath.Glob(filepath.Join(t.baseDir, "run-*"))
+	for _, dir := range dirs {
+		if _, ok := inUse[dir]; !ok {
+			os.RemoveAll(dir)
+		}
+	}
+}
+
+// KillAll 结束本程序启动的全部 Chrome 进程，退出前调用
+func (t *ChromeTracker) KillAll() {
+	for dir, pid := range t.inUse() {
+		t.release(pid, dir)
+	}
+	t.CleanupOrphans()
+}
+
+// Check 统计本程序的 Chrome 进程数，超过阈值时清理残留的进程
+func (t *ChromeTracker) Check(m *Metrics) {
+	procs, err := listProcesses()
+	if err != nil {
+		log.Printf("检查Chrome进程状态失败: %v", err)
+		return
+	}
+	own, orphans := t.ownProcesses(procs)
+	log.Printf("检测到 %d 个本程序的Chrome进程", len(own))
+	m.SetChromeProcesses(len(own))
+
+	// 只清理不再使用的进程，正在执行任务的 Chrome 不受影响
+	if len(own) > chromeProcessLimit && len(orphans) > 0 {
+		log.Printf("Chrome进程数量(%d)超过阈值，清理 %d 个残留的Chrome", len(own), len(orphans))
+		t.CleanupOrphans()
+
+		// 清理后再次统计
+		time.Sleep(5 * time.Second)
+		if procs, err := listProcesses(); err == nil {
+			own, _ := t.ownProcesses(procs)
+			m.SetChromeProcesses(len(own))
+		}
+	}
+}
+
+// Monitor 定期检查本程序的 Chrome 进程，直到 stop 被关闭
+func (t *ChromeTracker) Monitor(stop chan struct{}, m *Metrics) {
+	log.Println("开始监控Chrome进程...")
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+
+	// 立即执行一次检查
+	t.Check(m)
+
+	for {
+		select {
+		case <-ticker.C:
+			t.Check(m)
+		case <-stop:
+			log.Println("Chrome进程监控已停止")
+			return
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestChromeTrackerOwnProcesses(t *testing.T) {
+	tracker := NewChromeTracker(t.TempDir())
+	running := filepath.Join(tracker.baseDir, "run-1")
+	stale := filepath.Join(tracker.baseDir, "run-2")
+	tracker.dirs[running] = 100
+
+	procs := []chromeProcess{
+		// 正在使用的 Chrome 及其子进程
+		{PID: 100, Group: 100, Args: "chromium --user-data-dir=" + running},
+		{PID: 101, Group: 100, Args: "chromium --type=renderer"},
+		// 之前运行残留的 Chrome 及其子进程
+		{PID: 200, Group: 200, Args: "chromium --user-data-dir=" + stale},
+		{PID: 201, Group: 200, Args: "chromium --type=gpu-process"},
+		// 其他程序的浏览器
+		{PID: 300, Group: 300, Args: "chromium --user-data-dir=/home/other/.config/chromium"},
+		{PID: 301, Group: 300, Args: "chromium --type=renderer"},
+		{PID: 400, Group: 400, Args: "/usr/bin/google-chrome"},
+	}
+
+	own, orphans := tracker.ownProcesses(procs)
+	var pids []int
+	for _, p := range own {
+		pids = append(pids, p.PID)
+	}
+	if want := []int{100, 101, 200, 201}; !slices.Equal(pids, want) {
+		t.Errorf("本程序的进程为 %v，期望 %v", pids, want)
+	}
+	if want := []int{200}; !slices.Equal(orphans, want) {
+		t.Errorf("残留的进程为 %v，期望 %v", orphans, want)
+	}
+}
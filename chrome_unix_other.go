@@ -0,0 +1,35 @@
+//go:build unix && !linux
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setParentDeathSignal 只有 Linux 支持父进程退出信号
+func setParentDeathSignal(attr *syscall.SysProcAttr) {}
+
+// listProcesses 通过 ps 读取所有进程的进程组和命令行
+func listProcesses() ([]chromeProcess, error) {
+	output, err := exec.Command("ps", "-ax", "-o", "pid=,pgid=,command=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []chromeProcess
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		pgid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		procs = append(procs, chromeProcess{PID: pid, Group: pgid, Args: strings.Join(fields[2:], " ")})
+	}
+	return procs, nil
+}
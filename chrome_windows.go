@@ -0,0 +1,65 @@
+//go:build windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setChromeProcAttr 让 Chrome 在新的进程组中运行，不接收本程序控制台的 Ctrl+C
+func setChromeProcAttr(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// killChromeTree 结束 pid 及其全部子进程
+func killChromeTree(pid int) error {
+	output, err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).CombinedOutput()
+	if err != nil {
+		// 进程已退出时不报错
+		if strings.Contains(string(output), "not found") || strings.Contains(string(output), "没有找到") {
+			return nil
+		}
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// listProcesses 通过 PowerShell 读取所有 Chrome 进程的父进程和命令行，Group 为父进程 ID
+func listProcesses() ([]chromeProcess, error) {
+	const script = `Get-CimInstance Win32_Process -Filter "Name like '%chrom%'" | ` +
+		`Select-Object ProcessId,ParentProcessId,CommandLine | ConvertTo-Json -Compress`
+	output, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, err
+	}
+	output = []byte(strings.TrimSpace(string(output)))
+	if len(output) == 0 {
+		return nil, nil
+	}
+
+	var items []struct {
+		ProcessId       int
+		ParentProcessId int
+		CommandLine     string
+	}
+	// 只有一个进程时 ConvertTo-Json 输出的是对象而不是数组
+	if output[0] == '{' {
+		output = append(append([]byte{'['}, output...), ']')
+	}
+	if err := json.Unmarshal(output, &items); err != nil {
+		return nil, fmt.Errorf("解析进程列表失败: %w", err)
+	}
+	procs := make([]chromeProcess, 0, len(items))
+	for _, item := range items {
+		procs = append(procs, chromeProcess{PID: item.ProcessId, Group: item.ParentProcessId, Args: item.CommandLine})
+	}
+	return procs, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// 子命令的退出码
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command 一个子命令
+type command struct {
+	Name  string
+	Usage string
+	Run   func(a *App) int
+}
+
+// commands 可用的子命令，未指定时执行 daemon
+var commands = []command{
+	{Name: "daemon", Usage: "按 cron 表达式常驻运行（默认）", Run: runDaemon},
+	{Name: "run-once", Usage: "执行一次完整任务后退出，失败时退出码为 1", Run: runOnce},
+	{Name: "login", Usage: "重新登录并刷新 cookies", Run: runLogin},
+	{Name: "checkin", Usage: "只签到，不回帖", Run: runCheckIn},
+	{Name: "points", Usage: "输出用户积分信息", Run: runPoints},
+	{Name: "verify", Usage: "检查每个页面的选择器是否可用，不回帖也不签到", Run: runVerify},
+	{Name: "notify-test", Usage: "通过每个已配置的通知方式发送测试消息", Run: runNotifyTest},
+}
+
+// findCommand 按名称查找子命令
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// printUsage 输出命令行用法
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "用法: %s [选项] [命令]\n\n命令:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.Name, cmd.Usage)
+	}
+	fmt.Fprintln(out, "\n选项:")
+	flag.PrintDefaults()
+}
+
+// runDaemon 启动调度器等后台任务，收到退出信号后清理资源
+func runDaemon(a *App) int {
+	log.Println("程序启动...")
+	a.Start()
+
+	// 设置信号处理
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	// 保持程序运行
+	log.Println("程序已启动，按Ctrl+C停止")
+
+	// 等待中断信号
+	<-c
+	log.Println("收到退出信号，正在清理资源...")
+	a.Shutdown()
+	log.Println("程序已安全退出")
+	return exitOK
+}
+
+// runOnce 执行一次任务流水线，今天已完成时直接成功退出，失败时发送通知但不安排重试
+func runOnce(a *App) int {
+	defer a.Close()
+
+	if a.todayTaskDone() {
+		log.Println("今天的任务已全部完成，无需执行")
+		return exitOK
+	}
+	if err := a.state.BeginRun(time.Now()); err != nil {
+		log.Printf("保存任务状态失败: %v", err)
+	}
+	if err := a.runPipeline(); err != nil {
+		a.notifyFailure(err, 0)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runLogin 不使用已保存的 cookies 重新登录，登录成功后才覆盖保存的 cookies
+func runLogin(a *App) int {
+	driver, err := a.newDriver()
+	if err != nil {
+		log.Printf("创建浏览器失败: %v", err)
+		return exitFailure
+	}
+	defer driver.Close()
+
+	if err := driver.Relogin(); err != nil {
+		log.Printf("登录失败: %v", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runCheckIn 只执行签到，结果记入状态文件，之后的定时任务不会重复签到
+func runCheckIn(a *App) int {
+	driver, err := a.openSession()
+	if err != nil {
+		log.Println(err)
+		return exitFailure
+	}
+	defer driver.Close()
+
+	result, err := driver.CheckIn()
+	var output StepOutput
+	if err == nil {
+		output, err = checkInOutcome(result)
+	}
+	a.state.RecordStep("checkin", output, err)
+	if err != nil {
+		if errors.Is(err, ErrSessionExpired) {
+			a.invalidateSession()
+		}
+		log.Printf("签到失败: %v", err)
+		return exitFailure
+	}
+	a.state.MarkCheckedIn()
+	fmt.Println(result.Summary())
+	if result.Message != "" {
+		fmt.Println(result.Message)
+	}
+	return exitOK
+}
+
+// runPoints 输出用户积分信息
+func runPoints(a *App) int {
+	info, err := a.fetchUserInfo()
+	if err != nil {
+		log.Printf("获取积分失败: %v", err)
+		return exitFailure
+	}
+	fmt.Println(info)
+	return exitOK
+}
+
+// runVerify 使用已保存的 cookies 打开流程中的每个页面并输出选择器检查报告，任一页面失败时退出码为 1
+func runVerify(a *App) int {
+	driver, err := a.newDriver()
+	if err != nil {
+		log.Printf("创建浏览器失败: %v", err)
+		return exitFailure
+	}
+	defer driver.Close()
+
+	reports := driver.Verify()
+	fmt.Print(formatVerifyReport(reports))
+	if !verifyPassed(reports) {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runNotifyTest 逐个通知方式发送测试消息并输出结果，任一失败时退出码为 1
+func runNotifyTest(a *App) int {
+	defer a.Close()
+
+	notifiers := []Notifier{a.notifier}
+	if m, ok := a.notifier.(*MultiNotifier); ok {
+		notifiers = m.notifiers
+	}
+	if len(notifiers) == 0 {
+		log.Println("未配置任何通知方式")
+		return exitFailure
+	}
+
+	msg := Message{
+		Kind:  MessageInfo,
+		Title: "hjd2048 测试通知",
+		Text:  fmt.Sprintf("🔔 这是一条测试消息\n时间: %s", time.Now().Format("2006-01-02 15:04:05")),
+	}
+	code := exitOK
+	for _, n := range notifiers {
+		err := n.Notify(msg)
+		switch {
+		case errors.Is(err, ErrNotificationDeferred):
+			fmt.Printf("%-10s 发送失败，已保存待补发: %v\n", n.Name(), err)
+			code = exitFailure
+		case err != nil:
+			fmt.Printf("%-10s 发送失败: %v\n", n.Name(), err)
+			code = exitFailure
+		default:
+			fmt.Printf("%-10s 发送成功\n", n.Name())
+		}
+	}
+	return code
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile 默认配置文件，不存在时只使用环境变量
+const DefaultConfigFile = "config.yaml"
+
+// redactedValue 打印配置时用于替换敏感信息
+const redactedValue = "******"
+
+// cronParser 与调度器一致的 cron 解析器（带秒字段）
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Duration 支持 "30m" 这样的时间字符串，纯数字按分钟处理，与 RETRY_INTERVAL 的旧格式兼容
+type Duration time.Duration
+
+// UnmarshalYAML 解析 YAML 中的时间
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	v, err := parseMinutesOrDuration(node.Value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalYAML 输出为时间字符串
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+// parseMinutesOrDuration 纯数字按分钟解析，否则按 time.ParseDuration 解析
+func parseMinutesOrDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if minutes, err := strconv.Atoi(s); err == nil {
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析时间 %q，应为分钟数或如 30m 的时间", s)
+	}
+	return d, nil
+}
+
+// Config 程序的全部配置，可由配置文件提供，再由环境变量覆盖
+type Config struct {
+	Site struct {
+		BaseURL         string `yaml:"base_url"`
+		LoginSection    string `yaml:"login_section"`
+		ReplySection    string `yaml:"reply_section"`
+		CheckInSection  string `yaml:"check_in_section"`
+		UserInfoSection string `yaml:"user_info_section"`
+		// Profile 选择器文件，其中的值覆盖 selectors
+		Profile string `yaml:"profile"`
+		// Driver 访问论坛的方式：browser 使用 Chrome，http 直接提交表单
+		Driver string `yaml:"driver"`
+	} `yaml:"site"`
+
+	// Selectors 论坛页面的选择器，默认适配 2048 核基地
+	Selectors SiteProfile `yaml:"selectors"`
+
+	Account struct {
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		// SecurityQuestion 安全问题序号，0 为无安全问题
+		SecurityQuestion string `yaml:"security_question"`
+		SecurityAnswer   string `yaml:"security_answer"`
+	} `yaml:"account"`
+
+	Browser struct {
+		Headless        bool   `yaml:"headless"`
+		ChromePath      string `yaml:"chrome_path"`
+		ForceKillChrome bool   `yaml:"force_kill_chrome"`
+		// Persistent 常驻运行时只启动一个 Chrome，每次执行打开新的标签页
+		Persistent bool `yaml:"persistent"`
+		// UserDataDir 本程序专用的 Chrome 用户数据目录，用于区分本程序启动的 Chrome 进程
+		UserDataDir string `yaml:"user_data_dir"`
+		// RemoteURL 远程 Chrome 的调试地址，如 ws://chrome:9222，设置后不再在本地启动 Chrome，连接失败时才在本地启动
+		RemoteURL string `yaml:"remote_url"`
+	} `yaml:"browser"`
+
+	Schedule struct {
+		Cron          string   `yaml:"cron"`
+		RunOnStart    bool     `yaml:"run_on_start"`
+		RetryInterval Duration `yaml:"retry_interval"`
+		WaitingTime   int      `yaml:"waiting_time"`
+	} `yaml:"schedule"`
+
+	Telegram struct {
+		BotToken       string   `yaml:"bot_token"`
+		ChatIDs        []string `yaml:"chat_ids"`
+		SuccessChatIDs []string `yaml:"success_chat_ids"`
+		FailureChatIDs []string `yaml:"failure_chat_ids"`
+		Commands       bool     `yaml:"commands"`
+		OutboxFile     string   `yaml:"outbox_file"`
+	} `yaml:"telegram"`
+
+	Notify struct {
+		// Enabled 为空时启用所有已配置的通知方式
+		Enabled  []string `yaml:"enabled"`
+		DingTalk struct {
+			Webhook string `yaml:"webhook"`
+			Secret  string `yaml:"secret"`
+		} `yaml:"dingtalk"`
+		WeCom struct {
+			Webhook string `yaml:"webhook"`
+		} `yaml:"wecom"`
+		Email struct {
+			Host     string   `yaml:"host"`
+			Port     string   `yaml:"port"`
+			Username string   `yaml:"username"`
+			Password string   `yaml:"password"`
+			From     string   `yaml:"from"`
+			To       []string `yaml:"to"`
+		} `yaml:"email"`
+		Bark struct {
+			URL string `yaml:"url"`
+		} `yaml:"bark"`
+		ServerChan struct {
+			SendKey string `yaml:"send_key"`
+		} `yaml:"serverchan"`
+		Webhook struct {
+			URL string `yaml:"url"`
+		} `yaml:"webhook"`
+	} `yaml:"notify"`
+
+	HTTP struct {
+		Addr string `yaml:"addr"`
+		// AllowRemote 允许绑定非本机地址。接口没有认证，开启前请确认只有可信的网络能访问
+		AllowRemote bool `yaml:"allow_remote"`
+	} `yaml:"http"`
+
+	Storage struct {
+		StateFile         string `yaml:"state_file"`
+		PointsHistoryFile string `yaml:"points_history_file"`
+	} `yaml:"storage"`
+
+	Reports struct {
+		Weekly  string `yaml:"weekly"`
+		Monthly string `yaml:"monthly"`
+	} `yaml:"reports"`
+
+	// 以下字段由 Validate 根据上面的字符串解析得到
+	chatTargets        []ChatTarget
+	successChatTargets []ChatTarget
+	failureChatTargets []ChatTarget
+}
+
+// defaultConfig 返回带默认值的配置
+func defaultConfig() *Config {
+	cfg := &Config{}
+	cfg.Site.LoginSection = "login.php"
+	cfg.Site.ReplySection = "thread.php?fid=57"
+	cfg.Site.CheckInSection = "hack.php?H_name=qiandao"
+	cfg.Site.UserInfoSection = "u.php?action=show"
+	cfg.Site.Driver = DriverBrowser
+	cfg.Selectors = defaultSiteProfile()
+	cfg.Browser.UserDataDir = DefaultChromeUserDataDir
+	cfg.Schedule.Cron = "0 20 0 * * *"
+	cfg.Schedule.RetryInterval = Duration(30 * time.Minute)
+	cfg.Schedule.WaitingTime = 1
+	cfg.Telegram.OutboxFile = DefaultTelegramOutboxFile
+	cfg.Notify.Email.Port = "465"
+	cfg.Storage.StateFile = DefaultStateFile
+	cfg.Storage.PointsHistoryFile = DefaultPointsHistoryFile
+	return cfg
+}
+
+// LoadConfig 依次应用默认值、配置文件和环境变量，并校验所有字段。
+// path 为空时尝试读取默认配置文件，文件不存在不视为错误。
+// 校验失败时同时返回合并后的配置，便于 --print-config 排查；配置文件无法读取或解析时配置为 nil
+func LoadConfig(path string) (*Config, error) {
+	cfg := defaultConfig()
+
+	explicit := path != ""
+	if !explicit {
+		path = DefaultConfigFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// 没有配置文件时只使用环境变量
+	default:
+		return nil, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+
+	errs := cfg.applyEnv()
+	if cfg.Site.Profile != "" {
+		if err := loadSiteProfile(cfg.Site.Profile, &cfg.Selectors); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, cfg.Validate()...)
+	return cfg, errors.Join(errs...)
+}
+
+// applyEnv 用已设置的环境变量覆盖配置，返回所有无法解析的值
+func (c *Config) applyEnv() []error {
+	var errs []error
+
+	str := func(env string, dst *string) {
+		if v := os.Getenv(env); v != "" {
+			*dst = v
+		}
+	}
+	list := func(env string, dst *[]string) {
+		if v := os.Getenv(env); v != "" {
+			*dst = splitList(v)
+		}
+	}
+	boolean := func(env string, dst *bool) {
+		if v := os.Getenv(env); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s=%q 不是有效的布尔值", env, v))
+				return
+			}
+			*dst = b
+		}
+	}
+
+	str("BASE_URL", &c.Site.BaseURL)
+	str("LOGIN_SECTION", &c.Site.LoginSection)
+	str("REPLY_SECTION", &c.Site.ReplySection)
+	str("CHECK_IN_SECTION", &c.Site.CheckInSection)
+	str("USER_INFO_SECTION", &c.Site.UserInfoSection)
+	str("SITE_PROFILE", &c.Site.Profile)
+	str("SITE_DRIVER", &c.Site.Driver)
+
+	str("FORUM_USERNAME", &c.Account.Username)
+	str("FORUM_PASSWORD", &c.Account.Password)
+	str("SECURITY_QUESTION", &c.Account.SecurityQuestion)
+	str("SECURITY_ANSWER", &c.Account.SecurityAnswer)
+
+	boolean("ENABLE_HEADLESS", &c.Browser.Headless)
+	str("CHROME_PATH", &c.Browser.ChromePath)
+	boolean("FORCE_KILL_CHROME", &c.Browser.ForceKillChrome)
+	boolean("PERSISTENT_BROWSER", &c.Browser.Persistent)
+	str("CHROME_USER_DATA_DIR", &c.Browser.UserDataDir)
+	str("CHROME_REMOTE_URL", &c.Browser.RemoteURL)
+
+	str("CRON_SCHEDULE", &c.Schedule.Cron)
+	boolean("RUN_ON_START", &c.Schedule.RunOnStart)
+	if v := os.Getenv("RETRY_INTERVAL"); v != "" {
+		d, err := parseMinutesOrDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("RETRY_INTERVAL: %w", err))
+		} else {
+			c.Schedule.RetryInterval = Duration(d)
+		}
+	}
+	if v := os.Getenv("WAITING_TIME"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("WAITING_TIME=%q 不是有效的整数", v))
+		} else {
+			c.Schedule.WaitingTime = n
+		}
+	}
+
+	str("TELEGRAM_BOT_TOKEN", &c.Telegram.BotToken)
+	list("TELEGRAM_CHAT_ID", &c.Telegram.ChatIDs)
+	list("TELEGRAM_SUCCESS_CHAT_IDS", &c.Telegram.SuccessChatIDs)
+	list("TELEGRAM_FAILURE_CHAT_IDS", &c.Telegram.FailureChatIDs)
+	boolean("ENABLE_TELEGRAM_COMMANDS", &c.Telegram.Commands)
+	str("TELEGRAM_OUTBOX_FILE", &c.Telegram.OutboxFile)
+
+	list("NOTIFIERS", &c.Notify.Enabled)
+	str("DINGTALK_WEBHOOK", &c.Notify.DingTalk.Webhook)
+	str("DINGTALK_SECRET", &c.Notify.DingTalk.Secret)
+	str("WECOM_WEBHOOK", &c.Notify.WeCom.Webhook)
+	str("SMTP_HOST", &c.Notify.Email.Host)
+	str("SMTP_PORT", &c.Notify.Email.Port)
+	str("SMTP_USERNAME", &c.Notify.Email.Username)
+	str("SMTP_PASSWORD", &c.Notify.Email.Password)
+	str("SMTP_FROM", &c.Notify.Email.From)
+	list("SMTP_TO", &c.Notify.Email.To)
+	str("BARK_URL", &c.Notify.Bark.URL)
+	str("SERVERCHAN_SENDKEY", &c.Notify.ServerChan.SendKey)
+	str("WEBHOOK_URL", &c.Notify.Webhook.URL)
+
+	str("HTTP_ADDR", &c.HTTP.Addr)
+	boolean("HTTP_ALLOW_REMOTE", &c.HTTP.AllowRemote)
+
+	str("STATE_FILE", &c.Storage.StateFile)
+	str("POINTS_HISTORY_FILE", &c.Storage.PointsHistoryFile)
+
+	str("POINTS_WEEKLY_REPORT", &c.Reports.Weekly)
+	str("POINTS_MONTHLY_REPORT", &c.Reports.Monthly)
+
+	return errs
+}
+
+// knownNotifiers 支持的通知方式名称
+var knownNotifiers = map[string]bool{
+	"telegram": true, "dingtalk": true, "wecom": true, "email": true,
+	"bark": true, "serverchan": true, "webhook": true,
+}
+
+// Validate 校验配置，一次性返回所有错误
+func (c *Config) Validate() []error {
+	var errs []error
+	addErr := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Site.BaseURL == "" {
+		addErr("site.base_url (BASE_URL) 不能为空")
+	} else if u, err := url.Parse(c.Site.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		addErr("site.base_url (BASE_URL) %q 不是有效的 http(s) 地址", c.Site.BaseURL)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"site.login_section (LOGIN_SECTION)", c.Site.LoginSection},
+		{"site.reply_section (REPLY_SECTION)", c.Site.ReplySection},
+		{"site.check_in_section (CHECK_IN_SECTION)", c.Site.CheckInSection},
+		{"site.user_info_section (USER_INFO_SECTION)", c.Site.UserInfoSection},
+	} {
+		if field.value == "" {
+			addErr("%s 不能为空", field.name)
+		}
+	}
+
+	switch c.Site.Driver {
+	case DriverBrowser:
+	case DriverHTTP:
+		login := c.Selectors.Login
+		if login.UsernameField == "" || login.PasswordField == "" {
+			addErr("使用 http 驱动时 selectors.login.username_field 和 selectors.login.password_field 不能为空")
+		}
+	default:
+		addErr("site.driver (SITE_DRIVER) %q 应为 %s 或 %s", c.Site.Driver, DriverBrowser, DriverHTTP)
+	}
+	errs = append(errs, c.Selectors.validate()...)
+
+	if c.Account.Username == "" {
+		addErr("account.username (FORUM_USERNAME) 不能为空")
+	}
+	if c.Account.Password == "" {
+		addErr("account.password (FORUM_PASSWORD) 不能为空")
+	}
+	if q := c.Account.SecurityQuestion; q != "" {
+		if n, err := strconv.Atoi(q); err != nil || n < 0 || n > 8 {
+			addErr("account.security_question (SECURITY_QUESTION) %q 应为 0 到 8 之间的整数", q)
+		}
+	}
+
+	if _, err := cronParser.Parse(c.Schedule.Cron); err != nil {
+		addErr("schedule.cron (CRON_SCHEDULE) %q 无法解析: %v", c.Schedule.Cron, err)
+	}
+	if c.Schedule.RetryInterval <= 0 {
+		addErr("schedule.retry_interval (RETRY_INTERVAL) 必须大于0")
+	}
+	if c.Schedule.WaitingTime < 0 {
+		addErr("schedule.waiting_time (WAITING_TIME) 不能为负数")
+	}
+
+	if c.Browser.RemoteURL != "" {
+		u, err := url.Parse(c.Browser.RemoteURL)
+		if err != nil || u.Host == "" || (u.Scheme != "ws" && u.Scheme != "wss" && u.Scheme != "http" && u.Scheme != "https") {
+			addErr("browser.remote_url (CHROME_REMOTE_URL) %q 不是有效的 ws(s) 或 http(s) 地址", c.Browser.RemoteURL)
+		}
+	}
+
+	for _, field := range []struct {
+		name   string
+		values []string
+		dst    *[]ChatTarget
+	}{
+		{"telegram.chat_ids (TELEGRAM_CHAT_ID)", c.Telegram.ChatIDs, &c.chatTargets},
+		{"telegram.success_chat_ids (TELEGRAM_SUCCESS_CHAT_IDS)", c.Telegram.SuccessChatIDs, &c.successChatTargets},
+		{"telegram.failure_chat_ids (TELEGRAM_FAILURE_CHAT_IDS)", c.Telegram.FailureChatIDs, &c.failureChatTargets},
+	} {
+		targets, err := parseChatTargets(strings.Join(field.values, ","))
+		if err != nil {
+			addErr("%s: %v", field.name, err)
+			continue
+		}
+		*field.dst = targets
+	}
+	hasChats := len(c.chatTargets)+len(c.successChatTargets)+len(c.failureChatTargets) > 0
+	if hasChats && c.Telegram.BotToken == "" {
+		addErr("已配置 Telegram chat_id，但 telegram.bot_token (TELEGRAM_BOT_TOKEN) 为空")
+	}
+	if c.Telegram.Commands && c.Telegram.BotToken == "" {
+		addErr("telegram.commands (ENABLE_TELEGRAM_COMMANDS) 需要配置 telegram.bot_token")
+	}
+
+	for _, name := range c.Notify.Enabled {
+		if !knownNotifiers[strings.ToLower(name)] {
+			addErr("notify.enabled (NOTIFIERS) 包含未知的通知方式 %q", name)
+		}
+	}
+	if c.Notify.Email.Host != "" {
+		if _, err := strconv.Atoi(c.Notify.Email.Port); err != nil {
+			addErr("notify.email.port (SMTP_PORT) %q 不是有效的端口", c.Notify.Email.Port)
+		}
+	}
+
+	if c.HTTP.Addr != "" {
+		host, _, err := net.SplitHostPort(c.HTTP.Addr)
+		switch {
+		case err != nil:
+			addErr("http.addr (HTTP_ADDR) %q 无效: %v", c.HTTP.Addr, err)
+		// 控制接口没有认证，默认只允许绑定本机
+		case !c.HTTP.AllowRemote && !isLoopbackHost(host):
+			addErr("http.addr (HTTP_ADDR) %q 不是本机地址，接口没有认证，请绑定 127.0.0.1，或设置 http.allow_remote (HTTP_ALLOW_REMOTE) 允许其他地址访问", c.HTTP.Addr)
+		}
+	}
+
+	for _, field := range []struct{ name, spec string }{
+		{"reports.weekly (POINTS_WEEKLY_REPORT)", c.Reports.Weekly},
+		{"reports.monthly (POINTS_MONTHLY_REPORT)", c.Reports.Monthly},
+	} {
+		if field.spec == "" {
+			continue
+		}
+		if _, err := cronParser.Parse(field.spec); err != nil {
+			addErr("%s %q 无法解析: %v", field.name, field.spec, err)
+		}
+	}
+
+	return errs
+}
+
+// Redacted 返回隐藏了密码、Token 等敏感信息的副本
+func (c *Config) Redacted() Config {
+	r := *c
+	redact := func(s *string) {
+		if *s != "" {
+			*s = redactedValue
+		}
+	}
+	redact(&r.Account.Password)
+	redact(&r.Account.SecurityAnswer)
+	redact(&r.Telegram.BotToken)
+	redact(&r.Notify.DingTalk.Webhook)
+	redact(&r.Notify.DingTalk.Secret)
+	redact(&r.Notify.WeCom.Webhook)
+	redact(&r.Notify.Email.Password)
+	redact(&r.Notify.Bark.URL)
+	redact(&r.Notify.ServerChan.SendKey)
+	redact(&r.Notify.Webhook.URL)
+	return r
+}
+
+// printConfig 以 YAML 格式输出隐藏敏感信息后的配置
+func printConfig(c *Config) error {
+	redacted := c.Redacted()
+	out, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// splitList 按逗号拆分并去掉空白项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isLoopbackHost 判断监听地址是否只在本机可访问，为空时监听所有网卡
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig 返回可以通过校验的最小配置
+func validConfig() *Config {
+	cfg := defaultConfig()
+	cfg.Site.BaseURL = "https://hjd2048.com/2048/"
+	cfg.Account.Username = "testuser"
+	cfg.Account.Password = "secret"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *Config)
+		// want 为期望的错误中包含的文本，为空表示校验通过
+		want []string
+	}{
+		{name: "最小配置", configure: func(cfg *Config) {}},
+		{
+			name: "一次列出所有错误",
+			configure: func(cfg *Config) {
+				cfg.Site.BaseURL = "ftp://example.com"
+				cfg.Account.Username = ""
+				cfg.Schedule.Cron = "every day"
+				cfg.Schedule.RetryInterval = 0
+			},
+			want: []string{"site.base_url", "account.username", "schedule.cron", "schedule.retry_interval"},
+		},
+		{
+			name:      "重试间隔小于5分钟",
+			configure: func(cfg *Config) { cfg.Schedule.RetryInterval = Duration(time.Minute) },
+		},
+		{
+			name:      "安全问题超出范围",
+			configure: func(cfg *Config) { cfg.Account.SecurityQuestion = "9" },
+			want:      []string{"account.security_question"},
+		},
+		{
+			name:      "未知的驱动",
+			configure: func(cfg *Config) { cfg.Site.Driver = "curl" },
+			want:      []string{"site.driver"},
+		},
+		{
+			name: "http 驱动需要登录字段",
+			configure: func(cfg *Config) {
+				cfg.Site.Driver = DriverHTTP
+				cfg.Selectors.Login.PasswordField = ""
+			},
+			want: []string{"password_field"},
+		},
+		{
+			name:      "远程 Chrome 地址无效",
+			configure: func(cfg *Config) { cfg.Browser.RemoteURL = "localhost:9222" },
+			want:      []string{"browser.remote_url"},
+		},
+		{
+			name:      "HTTP 接口绑定本机",
+			configure: func(cfg *Config) { cfg.HTTP.Addr = "127.0.0.1:8080" },
+		},
+		{
+			name:      "HTTP 接口绑定所有网卡",
+			configure: func(cfg *Config) { cfg.HTTP.Addr = ":8080" },
+			want:      []string{"http.addr"},
+		},
+		{
+			name: "允许绑定非本机地址",
+			configure: func(cfg *Config) {
+				cfg.HTTP.Addr = "0.0.0.0:8080"
+				cfg.HTTP.AllowRemote = true
+			},
+		},
+		{
+			name:      "未知的通知方式",
+			configure: func(cfg *Config) { cfg.Notify.Enabled = []string{"telegram", "pager"} },
+			want:      []string{"pager"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.configure(cfg)
+			errs := cfg.Validate()
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Validate 返回 %d 个错误 %v，期望 %d 个", len(errs), errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("第 %d 个错误 %q 不包含 %q", i+1, errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `site:
+  base_url: https://hjd2048.com/2048/
+account:
+  username: fromfile
+  password: secret
+schedule:
+  retry_interval: 10m
+`
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("环境变量覆盖配置文件", func(t *testing.T) {
+		t.Setenv("FORUM_USERNAME", "fromenv")
+		t.Setenv("RETRY_INTERVAL", "15")
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig 返回错误: %v", err)
+		}
+		if cfg.Account.Username != "fromenv" || cfg.Account.Password != "secret" {
+			t.Errorf("账号 = %q/%q", cfg.Account.Username, cfg.Account.Password)
+		}
+		if got := time.Duration(cfg.Schedule.RetryInterval); got != 15*time.Minute {
+			t.Errorf("重试间隔 = %v，期望 15m", got)
+		}
+	})
+
+	t.Run("解析错误和校验错误一起返回", func(t *testing.T) {
+		t.Setenv("RETRY_INTERVAL", "soon")
+		t.Setenv("WAITING_TIME", "-1")
+		t.Setenv("BASE_URL", "not a url")
+		cfg, err := LoadConfig(path)
+		if err == nil {
+			t.Fatal("配置有误时 LoadConfig 应返回错误")
+		}
+		for _, want := range []string{"RETRY_INTERVAL", "waiting_time", "base_url"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("错误中缺少 %q:\n%v", want, err)
+			}
+		}
+		// 校验失败时仍返回合并后的配置，供 --print-config 使用
+		if cfg == nil || cfg.Account.Username != "fromfile" {
+			t.Errorf("校验失败时返回的配置 = %+v", cfg)
+		}
+	})
+
+	t.Run("指定的配置文件不存在", func(t *testing.T) {
+		cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+		if err == nil || cfg != nil {
+			t.Errorf("LoadConfig = %v, %v，期望返回读取错误", cfg, err)
+		}
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := validConfig()
+	cfg.Telegram.BotToken = "123:abc"
+	redacted := cfg.Redacted()
+	if redacted.Account.Password != redactedValue || redacted.Telegram.BotToken != redactedValue {
+		t.Errorf("敏感字段未隐藏: %+v", redacted.Account)
+	}
+	if redacted.Notify.Email.Password != "" {
+		t.Error("未设置的字段不应显示为已隐藏")
+	}
+	if cfg.Account.Password != "secret" {
+		t.Error("Redacted 不应修改原配置")
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"localhost": true,
+		"127.0.0.1": true,
+		"::1":       true,
+		"":          false,
+		"0.0.0.0":   false,
+		"10.0.0.5":  false,
+		"example":   false,
+	} {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v，期望 %v", host, got, want)
+		}
+	}
+}
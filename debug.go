@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// debugDir 步骤失败时保存页面快照的目录
+const debugDir = "debug"
+
+// debugSnapshotLayout 快照目录名中的时间格式
+const debugSnapshotLayout = "20060102-150405"
+
+// PageSnapshot 浏览器当前页面的内容
+type PageSnapshot struct {
+	URL        string
+	HTML       string
+	Screenshot []byte
+}
+
+// DebugSnapshot 保存到磁盘的快照文件
+type DebugSnapshot struct {
+	Dir        string
+	URL        string
+	Screenshot string
+	HTML       string
+}
+
+// saveDebugSnapshot 将页面快照保存到 debug/<时间>_<步骤名>/ 下，截图为空时只保存 HTML 和地址。
+// 快照包含登录后的页面，只允许当前用户读取
+func saveDebugSnapshot(step string, snap PageSnapshot) (*DebugSnapshot, error) {
+	dir := filepath.Join(debugDir, fmt.Sprintf("%s_%s", time.Now().Format(debugSnapshotLayout), step))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	saved := &DebugSnapshot{Dir: dir, URL: snap.URL, HTML: filepath.Join(dir, "page.html")}
+	if err := os.WriteFile(filepath.Join(dir, "url.txt"), []byte(snap.URL+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(saved.HTML, []byte(snap.HTML), 0600); err != nil {
+		return nil, err
+	}
+	if len(snap.Screenshot) > 0 {
+		saved.Screenshot = filepath.Join(dir, "screenshot.png")
+		if err := os.WriteFile(saved.Screenshot, snap.Screenshot, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+// 清理超过指定天数的页面快照
+func cleanupOldSnapshots(daysToKeep int) {
+	entries, err := os.ReadDir(debugDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("读取快照目录失败: %v", err)
+		}
+		return
+	}
+
+	// 计算截止时间
+	cutoff := time.Now().AddDate(0, 0, -daysToKeep)
+
+	// 快照目录名格式正则表达式
+	snapshotPattern := regexp.MustCompile(`^(\d{8}-\d{6})_`)
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		matches := snapshotPattern.FindStringSubmatch(entry.Name())
+		if len(matches) < 2 {
+			continue
+		}
+
+		created, err := time.ParseInLocation(debugSnapshotLayout, matches[1], time.Local)
+		if err != nil {
+			log.Printf("无法解析快照目录时间 %s: %v", entry.Name(), err)
+			continue
+		}
+
+		if created.Before(cutoff) {
+			if err := os.RemoveAll(filepath.Join(debugDir, entry.Name())); err != nil {
+				log.Printf("删除过期快照 %s 失败: %v", entry.Name(), err)
+			} else {
+				removed++
+			}
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("共清理了 %d 个过期页面快照", removed)
+	}
+}
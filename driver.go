@@ -0,0 +1,36 @@
+package main
+
+// 访问论坛的方式
+const (
+	// DriverBrowser 使用 Chrome 操作页面，兼容需要执行脚本的页面
+	DriverBrowser = "browser"
+	// DriverHTTP 直接发送 HTTP 请求提交表单，不需要 Chrome
+	DriverHTTP = "http"
+)
+
+// SiteDriver 封装与具体论坛程序相关的操作，任务流水线只通过它访问论坛
+type SiteDriver interface {
+	// Open 打开论坛回帖页并等待页头出现，确认论坛可以访问
+	Open() error
+	// Login 确保已登录：优先使用保存的 cookies，失效时提交登录表单并保存新的 cookies
+	Login() error
+	// Relogin 不使用保存的 cookies，清除当前会话后提交登录表单，成功后才覆盖保存的 cookies
+	Relogin() error
+	// IsLoggedIn 打开回帖页，根据页头判断是否已登录
+	IsLoggedIn() (bool, error)
+	// PickThread 从回帖版块中挑选要回复的帖子
+	PickThread() (title string, href string, err error)
+	// Reply 打开帖子并回复，确认回复出现在帖子中。论坛提示失败时返回错误，
+	// 既没有找到回复也没有提示时返回 Posted 为 false 的结果，不能重新提交
+	Reply(href string) (ReplyResult, error)
+	// CheckIn 签到并返回分类后的结果，是否算成功由调用方根据分类决定
+	CheckIn() (CheckInResult, error)
+	// FetchProfile 读取个人资料页中的积分
+	FetchProfile() (UserPoints, error)
+	// Verify 只读取页面，检查流程中每个页面的选择器，不回帖也不签到
+	Verify() []PageReport
+	// Snapshot 获取当前页面的快照，用于排查失败原因
+	Snapshot() (PageSnapshot, error)
+	// Close 释放浏览器等资源
+	Close()
+}
@@ -0,0 +1,734 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/network"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// httpUserAgent HTTP 驱动使用的 User-Agent，部分论坛会拦截非浏览器的请求
+const httpUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+
+// httpTimeout 单个请求的超时时间，与浏览器操作的超时一致
+const httpTimeout = 60 * time.Second
+
+// HTTPDriver 直接发送 HTTP 请求操作 PHPWind 论坛，不需要 Chrome。
+// 页面元素同样来自配置的选择器，登录表单的选择器可能是 XPath，因此按字段名填写。
+// 页面按响应头或 <meta> 声明的编码（如 GBK）解码，表单按页面编码提交。需要执行脚本的页面请使用浏览器驱动
+type HTTPDriver struct {
+	cfg     *Config
+	sel     SiteProfile
+	client  *http.Client
+	jar     *cookieRecorder
+	session *SessionStore
+
+	// 最近打开的页面，用于快照和在同一页面上查找元素。pageHTML 为解码后的 UTF-8 文本
+	pageURL      *url.URL
+	pageHTML     string
+	page         *goquery.Document
+	pageEncoding encoding.Encoding
+}
+
+// NewHTTPDriver 创建使用 HTTP 请求的驱动
+func NewHTTPDriver(cfg *Config, session *SessionStore) *HTTPDriver {
+	jar := newCookieRecorder()
+	return &HTTPDriver{
+		cfg:     cfg,
+		sel:     cfg.Selectors,
+		jar:     jar,
+		session: session,
+		client: &http.Client{
+			Jar:     jar,
+			Timeout: httpTimeout,
+		},
+	}
+}
+
+// Close 没有需要释放的资源
+func (d *HTTPDriver) Close() {}
+
+// Snapshot 返回最近打开的页面的地址和 HTML，没有截图
+func (d *HTTPDriver) Snapshot() (PageSnapshot, error) {
+	if d.pageURL == nil {
+		return PageSnapshot{}, errors.New("还没有打开任何页面")
+	}
+	return PageSnapshot{URL: d.pageURL.String(), HTML: d.pageHTML}, nil
+}
+
+// resolve 拼出论坛页面的完整地址
+func (d *HTTPDriver) resolve(section string) string {
+	return d.cfg.Site.BaseURL + section
+}
+
+// get 打开页面并作为当前页面
+func (d *HTTPDriver) get(rawURL string) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	return d.do(req)
+}
+
+// post 按当前页面的编码提交表单，跳转后的页面作为当前页面
+func (d *HTTPDriver) post(form *htmlForm) error {
+	body, err := encodeForm(form.values, d.pageEncoding)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, form.action, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if d.pageURL != nil {
+		req.Header.Set("Referer", d.pageURL.String())
+	}
+	return d.do(req)
+}
+
+// do 发送请求，按页面声明的编码解码后解析返回的页面
+func (d *HTTPDriver) do(req *http.Request) error {
+	req.Header.Set("User-Agent", httpUserAgent)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	enc, _, _ := charset.DetermineEncoding(body, resp.Header.Get("Content-Type"))
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return fmt.Errorf("解码页面 %s 失败: %w", req.URL, err)
+	}
+	// 出错时仍保存页面，便于排查
+	d.pageURL, d.pageHTML, d.pageEncoding = resp.Request.URL, string(decoded), enc
+	d.page, err = goquery.NewDocumentFromReader(strings.NewReader(d.pageHTML))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("请求 %s 失败: %s", req.URL, resp.Status)
+	}
+	return nil
+}
+
+// find 在当前页面中查找元素
+func (d *HTTPDriver) find(selector string) *goquery.Selection {
+	return d.page.Find(selector)
+}
+
+// text 返回当前页面中第一个匹配元素的文本，元素不存在时返回空字符串
+func (d *HTTPDriver) text(selector string) string {
+	if selector == "" {
+		return ""
+	}
+	return collapseSpace(d.find(selector).First().Text())
+}
+
+// Open 打开回帖页并确认页面中有页头
+func (d *HTTPDriver) Open() error {
+	if err := d.get(d.resolve(d.cfg.Site.ReplySection)); err != nil {
+		return err
+	}
+	if d.find(d.sel.Header.Selector).Length() == 0 {
+		return fmt.Errorf("页面中未找到页头 %s", d.sel.Header.Selector)
+	}
+	return nil
+}
+
+// IsLoggedIn 打开回帖页，根据页头判断是否已登录
+func (d *HTTPDriver) IsLoggedIn() (bool, error) {
+	if err := d.get(d.resolve(d.cfg.Site.ReplySection)); err != nil {
+		return false, err
+	}
+	return d.headerLoggedIn()
+}
+
+// headerLoggedIn 根据当前页面的页头判断是否已登录，判断方式与浏览器驱动相同
+func (d *HTTPDriver) headerLoggedIn() (bool, error) {
+	header := d.sel.Header
+	el := d.find(header.Selector).First()
+	if el.Length() == 0 {
+		return false, fmt.Errorf("页面中未找到页头 %s", header.Selector)
+	}
+	headerHTML, err := goquery.OuterHtml(el)
+	if err != nil {
+		return false, err
+	}
+	if strings.Contains(headerHTML, header.LoggedIn) {
+		return true, nil
+	}
+	return header.LoggedOut == "" || !strings.Contains(headerHTML, header.LoggedOut), nil
+}
+
+// Login 优先加载保存的 cookies，仍未登录时提交登录表单并保存 cookies
+func (d *HTTPDriver) Login() error {
+	loggedIn, err := d.restoreCookies()
+	if err != nil {
+		return err
+	}
+	if loggedIn {
+		log.Printf("使用已有的 cookies 登录成功")
+		return nil
+	}
+
+	if err := d.submitLogin(); err != nil {
+		return err
+	}
+	d.saveSession()
+	return nil
+}
+
+// Relogin 换用空的 cookie jar 后提交登录表单，成功后保存新的 cookies
+func (d *HTTPDriver) Relogin() error {
+	d.jar = newCookieRecorder()
+	d.client.Jar = d.jar
+	if err := d.submitLogin(); err != nil {
+		return err
+	}
+	d.saveSession()
+	return nil
+}
+
+// saveSession 保存登录后的 cookies，保存失败只影响下次是否需要重新登录，不影响本次执行
+func (d *HTTPDriver) saveSession() {
+	if err := d.session.Save(d.jar.Saved()); err != nil {
+		log.Printf("登录成功，但 cookies 保存失败: %v", err)
+		return
+	}
+	log.Printf("登录成功，cookies 已保存到 %s", d.session.Path())
+}
+
+// restoreCookies 加载未过期的 cookies 并打开回帖页，根据页头确认之后是否已登录
+func (d *HTTPDriver) restoreCookies() (bool, error) {
+	cookies, err := d.session.Load()
+	if err != nil {
+		log.Printf("%v，需要重新登录", err)
+		return false, nil
+	}
+	if len(cookies) == 0 {
+		return false, nil
+	}
+	d.jar.Restore(cookies)
+	loggedIn, err := d.IsLoggedIn()
+	if err == nil && !loggedIn {
+		log.Printf("已保存的 cookies 已失效，需要重新登录")
+	}
+	return loggedIn, err
+}
+
+// submitLogin 提交登录表单：用户名、密码、安全问题、答案，表单中的其他字段保持页面上的默认值
+func (d *HTTPDriver) submitLogin() error {
+	sel := d.sel.Login
+	account := d.cfg.Account
+	if err := d.get(d.resolve(d.cfg.Site.LoginSection)); err != nil {
+		return err
+	}
+	form, err := parseHTMLForm(d.pageURL, d.find(sel.Form).First())
+	if err != nil {
+		return fmt.Errorf("登录页 %w", err)
+	}
+	form.values.Set(sel.UsernameField, account.Username)
+	form.values.Set(sel.PasswordField, account.Password)
+	if sel.QuestionField != "" && account.SecurityQuestion != "" {
+		form.values.Set(sel.QuestionField, account.SecurityQuestion)
+	}
+	if sel.AnswerField != "" && account.SecurityAnswer != "" {
+		form.values.Set(sel.AnswerField, account.SecurityAnswer)
+	}
+	if err := d.post(form); err != nil {
+		log.Printf("登陆操作出错：%v", err)
+		return err
+	}
+
+	// 登录后的提示页可能没有页头，回到回帖页确认
+	notice := d.text(d.sel.Reply.Notice)
+	loggedIn, err := d.IsLoggedIn()
+	if err != nil {
+		return err
+	}
+	if !loggedIn {
+		if notice != "" {
+			return fmt.Errorf("登录失败，论坛提示: %s", notice)
+		}
+		return errors.New("提交登录表单后仍未登录")
+	}
+	return nil
+}
+
+// PickThread 打开回帖版块，取广告注释后第一个符合条件的帖子
+func (d *HTTPDriver) PickThread() (title string, href string, err error) {
+	if err = d.get(d.resolve(d.cfg.Site.ReplySection)); err != nil {
+		log.Printf("打开回帖页失败: %v", err)
+		return
+	}
+	return parseFirstPost(d.pageHTML, d.sel.Thread)
+}
+
+// Reply 打开帖子最后一页，提交回复表单，之后确认帖子中出现了本账号新的回复
+func (d *HTTPDriver) Reply(href string) (ReplyResult, error) {
+	sel := d.sel.Reply
+	username := d.cfg.Account.Username
+	// 在最后一页回复，提交前后都在最后一页查找，才能区分新旧回复
+	if err := d.get(d.resolve(href + sel.LastPage)); err != nil {
+		log.Printf("打开帖子失败: %v", err)
+		return ReplyResult{}, err
+	}
+	textarea := d.find(sel.Textarea).First()
+	form, err := parseHTMLForm(d.pageURL, textarea)
+	if err != nil {
+		return ReplyResult{}, fmt.Errorf("帖子页 %w", err)
+	}
+	name, ok := textarea.Attr("name")
+	if !ok || name == "" {
+		return ReplyResult{}, fmt.Errorf("回帖输入框 %s 没有 name 属性", sel.Textarea)
+	}
+
+	replyContent := randomReplyContent()
+	before := countOwnReplies(d.page, sel, username, replyContent)
+	form.values.Set(name, replyContent)
+	if err := d.post(form); err != nil {
+		log.Printf("提交回帖失败: %v", err)
+		return ReplyResult{Content: replyContent}, err
+	}
+
+	result, err := parseReplyResult(d.pageHTML, sel, username, replyContent, before)
+	if err != nil {
+		return result, err
+	}
+	if !result.Posted && sel.LastPage != "" {
+		// 提交后停留在提示页时，打开帖子最后一页再查找，提示页的文字比帖子页更有参考价值
+		notice := result.Message
+		if err := d.get(d.resolve(href + sel.LastPage)); err != nil {
+			log.Printf("打开帖子最后一页失败: %v", err)
+		} else if result, err = parseReplyResult(d.pageHTML, sel, username, replyContent, before); err != nil {
+			return result, err
+		}
+		if notice != "" {
+			result.Message = notice
+		}
+	}
+
+	if !result.Posted {
+		if result.Message != "" {
+			return result, fmt.Errorf("回帖未成功，论坛提示: %s", result.Message)
+		}
+		// 没有论坛提示时回帖可能已经成功，只是页面与选择器不符，重新提交会重复回帖
+		log.Printf("回帖后未在帖子中找到本账号新的回复，也没有论坛提示，无法确认回帖结果")
+	}
+	return result, nil
+}
+
+// CheckIn 到签到页面签到并对结果分类。页面已提示今天已签到、需要回帖或未登录时不再提交签到表单
+func (d *HTTPDriver) CheckIn() (CheckInResult, error) {
+	sel := d.sel.CheckIn
+	if err := d.get(d.resolve(d.cfg.Site.CheckInSection)); err != nil {
+		return CheckInResult{}, err
+	}
+	// 今天已签到时页面不会出现签到按钮，先检查提示文本
+	notice := d.text(sel.Notice)
+	if result := classifyCheckIn(notice); result.Status != CheckInUnknown && result.Status != CheckInSigned {
+		log.Printf("%s 签到页提示：%s", time.Now().Format("2006-01-02"), notice)
+		return result, nil
+	}
+
+	submit := d.find(sel.Submit).First()
+	if submit.Length() == 0 {
+		return CheckInResult{}, fmt.Errorf("签到页中未找到签到按钮 %s", sel.Submit)
+	}
+	form, err := parseHTMLForm(d.pageURL, submit)
+	if err != nil {
+		return CheckInResult{}, fmt.Errorf("签到页 %w", err)
+	}
+	// 随机选择一个心情
+	if sel.Mood != "" {
+		name, ok := d.find(sel.Mood).First().Attr("name")
+		if !ok || name == "" {
+			return CheckInResult{}, fmt.Errorf("签到页中未找到心情选项 %s", sel.Mood)
+		}
+		form.values.Set(name, sel.Moods[rand.IntN(len(sel.Moods))])
+	}
+	// 提交按钮带有 name 时一并提交，与点击按钮一致
+	if name, ok := submit.Attr("name"); ok && name != "" {
+		value, _ := submit.Attr("value")
+		form.values.Set(name, value)
+	}
+	if err := d.post(form); err != nil {
+		log.Printf("签到操作出错：%v", err)
+		return CheckInResult{}, err
+	}
+
+	resultText := d.text(sel.Notice)
+	log.Printf("%s 签到结果：%s", time.Now().Format("2006-01-02"), resultText)
+	return classifyCheckIn(resultText), nil
+}
+
+// FetchProfile 打开个人资料页并解析积分
+func (d *HTTPDriver) FetchProfile() (UserPoints, error) {
+	if err := d.get(d.resolve(d.cfg.Site.UserInfoSection)); err != nil {
+		return UserPoints{}, err
+	}
+	container := d.find(d.sel.Profile.Container).First()
+	if container.Length() == 0 {
+		return UserPoints{}, fmt.Errorf("个人资料页中未找到 %s", d.sel.Profile.Container)
+	}
+	infoHTML, err := goquery.OuterHtml(container)
+	if err != nil {
+		return UserPoints{}, err
+	}
+
+	points, err := parseUserInfo(infoHTML, d.sel.Profile)
+	if err != nil {
+		log.Printf("解析用户信息HTML失败: %v", err)
+		return UserPoints{}, err
+	}
+	if len(points.Raw) > 0 {
+		log.Printf("成功获取用户积分信息: %+v", points.Raw)
+	}
+	return points, nil
+}
+
+// inspect 检查当前页面中的选择器。没有执行样式，元素存在即视为可见
+func (d *HTTPDriver) inspect(report *PageReport, specs []selectorSpec) {
+	for _, spec := range specs {
+		if spec.selector == "" {
+			continue
+		}
+		found := d.find(spec.selector).Length() > 0
+		report.Checks = append(report.Checks, SelectorCheck{
+			Name:     spec.name,
+			Selector: spec.selector,
+			Found:    found,
+			Visible:  found,
+			Optional: spec.optional,
+		})
+	}
+}
+
+// fieldSelector 按 name 查找表单字段的选择器
+func fieldSelector(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(`[name=%q]`, name)
+}
+
+// Verify 依次打开流程中的每个页面并检查选择器是否存在。
+// 只使用已保存的 cookies 登录，不会提交任何表单
+func (d *HTTPDriver) Verify() []PageReport {
+	sel := d.sel
+	var reports []PageReport
+
+	// 登录页，在加载 cookies 之前检查，此时应显示登录表单。登录字段按 name 检查
+	login := PageReport{Page: "登录页", URL: d.resolve(d.cfg.Site.LoginSection)}
+	if login.Err = d.get(login.URL); login.Err == nil {
+		d.inspect(&login, []selectorSpec{
+			{name: "header.selector", selector: sel.Header.Selector},
+			{name: "login.form", selector: sel.Login.Form},
+			{name: "login.username_field", selector: fieldSelector(sel.Login.UsernameField)},
+			{name: "login.password_field", selector: fieldSelector(sel.Login.PasswordField)},
+			{name: "login.question_field", selector: fieldSelector(sel.Login.QuestionField), optional: true},
+			{name: "login.answer_field", selector: fieldSelector(sel.Login.AnswerField), optional: true},
+		})
+	}
+	reports = append(reports, login)
+
+	// 回帖版块，先用保存的 cookies 登录
+	list := PageReport{Page: "回帖版块", URL: d.resolve(d.cfg.Site.ReplySection)}
+	loggedIn, err := d.restoreCookies()
+	switch {
+	case err != nil:
+		list.Err = fmt.Errorf("加载 cookies 失败: %w", err)
+	case !loggedIn:
+		list.Err = errors.New("没有可用的 cookies 或 cookies 已失效，请先执行 login 命令")
+	}
+	if list.Err != nil {
+		list.Note = "后续页面需要登录，已跳过"
+		return append(reports, list)
+	}
+	d.inspect(&list, []selectorSpec{
+		{name: "thread.list", selector: sel.Thread.List},
+		{name: "thread.body", selector: sel.Thread.Body},
+		{name: "thread.row", selector: sel.Thread.Row},
+		{name: "thread.link", selector: sel.Thread.Link},
+	})
+	title, href, err := parseFirstPost(d.pageHTML, sel.Thread)
+	if list.Err = err; err == nil {
+		list.Note = "将回复的帖子: " + title
+	}
+	reports = append(reports, list)
+
+	// 帖子页，只检查回复表单，不提交
+	thread := PageReport{Page: "帖子页"}
+	if href == "" {
+		thread.Err = errors.New("没有可检查的帖子")
+	} else {
+		thread.URL = d.resolve(href)
+		if thread.Err = d.get(thread.URL); thread.Err == nil {
+			d.inspect(&thread, []selectorSpec{
+				{name: "reply.textarea", selector: sel.Reply.Textarea},
+				{name: "reply.submit", selector: sel.Reply.Submit},
+			})
+		}
+	}
+	reports = append(reports, thread)
+
+	// 签到页，今天已签到时不会出现签到按钮
+	checkIn := PageReport{Page: "签到页", URL: d.resolve(d.cfg.Site.CheckInSection)}
+	if checkIn.Err = d.get(checkIn.URL); checkIn.Err == nil {
+		if notice := d.text(sel.CheckIn.Notice); isAlreadyCheckedIn(notice) {
+			checkIn.Note = "今天已签到，未检查签到按钮: " + notice
+			d.inspect(&checkIn, []selectorSpec{
+				{name: "checkin.notice", selector: sel.CheckIn.Notice},
+			})
+		} else {
+			d.inspect(&checkIn, []selectorSpec{
+				{name: "checkin.mood", selector: sel.CheckIn.Mood},
+				{name: "checkin.submit", selector: sel.CheckIn.Submit},
+				// 签到前不一定有提示文本
+				{name: "checkin.notice", selector: sel.CheckIn.Notice, optional: true},
+			})
+		}
+	}
+	reports = append(reports, checkIn)
+
+	// 个人资料页，同时确认能解析出积分
+	profile := PageReport{Page: "个人资料页", URL: d.resolve(d.cfg.Site.UserInfoSection)}
+	if profile.Err = d.get(profile.URL); profile.Err == nil {
+		d.inspect(&profile, []selectorSpec{
+			{name: "profile.container", selector: sel.Profile.Container},
+			{name: "profile.rows", selector: sel.Profile.Rows},
+		})
+	}
+	if profile.OK() {
+		points, err := parseUserInfo(d.pageHTML, sel.Profile)
+		switch {
+		case err != nil:
+			profile.Err = err
+		case len(points.Raw) == 0:
+			profile.Err = errors.New("未解析到积分，请检查 profile.key 和 profile.value")
+		default:
+			profile.Note = "积分: " + points.String()
+		}
+	}
+	return append(reports, profile)
+}
+
+// encodeForm 将表单字段转换为页面编码后进行 URL 编码，与浏览器一样将编码中不存在的字符转为 &#NNNN;。
+// enc 为 nil 时按 UTF-8 提交
+func encodeForm(values url.Values, enc encoding.Encoding) (string, error) {
+	if enc == nil {
+		return values.Encode(), nil
+	}
+	encoder := encoding.HTMLEscapeUnsupported(enc.NewEncoder())
+	encoded := url.Values{}
+	for name, vs := range values {
+		key, err := encoder.String(name)
+		if err != nil {
+			return "", fmt.Errorf("表单字段 %s 编码失败: %w", name, err)
+		}
+		for _, v := range vs {
+			value, err := encoder.String(v)
+			if err != nil {
+				return "", fmt.Errorf("表单字段 %s 编码失败: %w", name, err)
+			}
+			encoded.Add(key, value)
+		}
+	}
+	return encoded.Encode(), nil
+}
+
+// htmlForm 页面中待提交的表单
+type htmlForm struct {
+	action string
+	values url.Values
+}
+
+// parseHTMLForm 找到 el 所在的表单，读取提交地址和各字段在页面上的默认值。
+// 未选中的单选框、复选框以及提交按钮不会包含在内
+func parseHTMLForm(page *url.URL, el *goquery.Selection) (*htmlForm, error) {
+	if el.Length() == 0 {
+		return nil, errors.New("未找到表单元素")
+	}
+	form := el.Closest("form")
+	if form.Length() == 0 {
+		form = el.Find("form").First()
+	}
+	if form.Length() == 0 {
+		return nil, errors.New("未找到元素所在的表单")
+	}
+
+	action, _ := form.Attr("action")
+	target, err := page.Parse(strings.TrimSpace(action))
+	if err != nil {
+		return nil, fmt.Errorf("表单地址 %q 无效: %w", action, err)
+	}
+
+	values := url.Values{}
+	form.Find("input[name], textarea[name], select[name]").Each(func(i int, field *goquery.Selection) {
+		name, _ := field.Attr("name")
+		if _, disabled := field.Attr("disabled"); disabled || name == "" {
+			return
+		}
+		switch goquery.NodeName(field) {
+		case "textarea":
+			values.Add(name, field.Text())
+		case "select":
+			option := field.Find("option[selected]").First()
+			if option.Length() == 0 {
+				option = field.Find("option").First()
+			}
+			if option.Length() > 0 {
+				value, ok := option.Attr("value")
+				if !ok {
+					value = strings.TrimSpace(option.Text())
+				}
+				values.Add(name, value)
+			}
+		default:
+			value, _ := field.Attr("value")
+			switch strings.ToLower(field.AttrOr("type", "text")) {
+			case "submit", "button", "image", "reset", "file":
+			case "checkbox", "radio":
+				if _, checked := field.Attr("checked"); checked {
+					if value == "" {
+						value = "on"
+					}
+					values.Add(name, value)
+				}
+			default:
+				values.Add(name, value)
+			}
+		}
+	})
+	return &htmlForm{action: target.String(), values: values}, nil
+}
+
+// cookieRecorder 在标准 cookie jar 之外记录每个 cookie 的域名、路径和过期时间，
+// 保存为与浏览器驱动相同的格式，两种驱动可以共用 cookies 文件
+type cookieRecorder struct {
+	*cookiejar.Jar
+
+	mu      sync.Mutex
+	cookies map[string]*network.Cookie
+}
+
+// newCookieRecorder 创建空的 cookie jar
+func newCookieRecorder() *cookieRecorder {
+	jar, _ := cookiejar.New(nil)
+	return &cookieRecorder{Jar: jar, cookies: make(map[string]*network.Cookie)}
+}
+
+// SetCookies 保存服务器设置的 cookies，同时记录下来
+func (r *cookieRecorder) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	r.Jar.SetCookies(u, cookies)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		domain := strings.TrimPrefix(c.Domain, ".")
+		if domain == "" {
+			domain = u.Hostname()
+		} else {
+			domain = "." + domain
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		key := c.Name + ";" + domain + ";" + path
+
+		var expires time.Time
+		switch {
+		case c.MaxAge < 0:
+			delete(r.cookies, key)
+			continue
+		case c.MaxAge > 0:
+			expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			expires = c.Expires
+		}
+		if !expires.IsZero() && !expires.After(now) {
+			delete(r.cookies, key)
+			continue
+		}
+
+		cookie := &network.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   domain,
+			Path:     path,
+			Expires:  -1,
+			Size:     int64(len(c.Name) + len(c.Value)),
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+			Session:  expires.IsZero(),
+			// 读取 cookies 文件时要求以下字段为有效值
+			Priority:     network.CookiePriorityMedium,
+			SourceScheme: network.CookieSourceSchemeNonSecure,
+			SourcePort:   -1,
+		}
+		if c.Secure {
+			cookie.SourceScheme = network.CookieSourceSchemeSecure
+		}
+		if !expires.IsZero() {
+			cookie.Expires = float64(expires.Unix())
+		}
+		r.cookies[key] = cookie
+	}
+}
+
+// Restore 将保存的 cookies 放入 jar
+func (r *cookieRecorder) Restore(cookies []*network.Cookie) {
+	for _, c := range cookies {
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		}
+		// 以点开头的是对子域名同样有效的 cookie，否则只对该主机有效
+		host := strings.TrimPrefix(c.Domain, ".")
+		if strings.HasPrefix(c.Domain, ".") {
+			cookie.Domain = host
+		}
+		if !c.Session && c.Expires > 0 {
+			cookie.Expires = time.UnixMilli(int64(c.Expires * 1000))
+		}
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		r.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: "/"}, []*http.Cookie{cookie})
+	}
+}
+
+// Saved 返回记录的全部 cookies，格式与浏览器驱动相同
+func (r *cookieRecorder) Saved() []*network.Cookie {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cookies := make([]*network.Cookie, 0, len(r.cookies))
+	for _, c := range r.cookies {
+		cookies = append(cookies, c)
+	}
+	return cookies
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseHTMLForm(t *testing.T) {
+	const page = `<div id="main"><form method="post" action="login.php?step=2">
+<div class="cc p10 regItem">
+	<input type="text" name="pwuser" value="">
+	<input type="password" name="pwpwd">
+	<select name="question"><option value="0">无</option><option value="4" selected>学校</option></select>
+	<input type="checkbox" name="cktime" value="31536000" checked>
+	<input type="checkbox" name="hideid" value="1">
+	<input type="radio" name="lgt" value="0" checked><input type="radio" name="lgt" value="1">
+	<input type="hidden" name="forward" value="index.php">
+	<input type="text" name="disabled" value="x" disabled>
+	<textarea name="note">默认内容</textarea>
+	<input type="submit" name="submit" value="登 录">
+</div></form></div>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/2048/login.php")
+
+	form, err := parseHTMLForm(base, doc.Find(".cc.p10.regItem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/2048/login.php?step=2"; form.action != want {
+		t.Errorf("action = %q，期望 %q", form.action, want)
+	}
+	want := url.Values{
+		"pwuser":   {""},
+		"pwpwd":    {""},
+		"question": {"4"},
+		"cktime":   {"31536000"},
+		"lgt":      {"0"},
+		"forward":  {"index.php"},
+		"note":     {"默认内容"},
+	}
+	if got := form.values.Encode(); got != want.Encode() {
+		t.Errorf("values = %s\n期望 %s", got, want.Encode())
+	}
+
+	if _, err := parseHTMLForm(base, doc.Find("#missing")); err == nil {
+		t.Error("元素不存在时应返回错误")
+	}
+}
+
+func TestHTTPDriverExecuteTask(t *testing.T) {
+	forum := newFakeForum(t)
+	app, recorder := newHTTPTestApp(t, forum)
+
+	app.executeTask()
+
+	state := app.state.Snapshot()
+	if !app.pipeline.Done(state) {
+		t.Fatalf("任务未完成，最近失败: %+v", state.LastStepError())
+	}
+	for _, name := range []string{"navigate", "login"} {
+		if !state.Steps[name].Success {
+			t.Errorf("步骤 %s 未记录成功: %+v", name, state.Steps[name])
+		}
+	}
+	if forum.Logins() != 1 || len(forum.Replies()) != 1 || !forum.Signed() {
+		t.Errorf("登录 %d 次，回帖 %v，签到 %v", forum.Logins(), forum.Replies(), forum.Signed())
+	}
+	messages := recorder.Messages()
+	if len(messages) != 1 || messages[0].Kind != MessageSuccess {
+		t.Fatalf("通知 %+v，期望一条成功通知", messages)
+	}
+	for _, want := range []string{"新片速递 第一集", "签到成功，获得 金币 5", "金币: 105"} {
+		if !strings.Contains(messages[0].Text, want) {
+			t.Errorf("通知内容缺少 %q:\n%s", want, messages[0].Text)
+		}
+	}
+
+	// 保存的 cookies 可以直接使用，不需要再次提交登录表单
+	if _, err := os.Stat(cookiesFile); err != nil {
+		t.Fatalf("cookies 未保存: %v", err)
+	}
+	driver := NewHTTPDriver(app.cfg, app.session)
+	if err := driver.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if forum.Logins() != 1 {
+		t.Errorf("登录次数 = %d，应使用保存的 cookies", forum.Logins())
+	}
+}
+
+func TestHTTPDriverGBK(t *testing.T) {
+	forum := newFakeForum(t)
+	forum.SetGBK(true)
+	forum.Username = "测试用户"
+	app, _ := newHTTPTestApp(t, forum)
+
+	if err := app.runPipeline(); err != nil {
+		t.Fatalf("GBK 页面上执行任务失败: %v", err)
+	}
+	replies := forum.Replies()
+	if len(replies) != 1 || !slices.Contains(ReplyContents, replies[0]) {
+		t.Errorf("回帖 %q，期望按 GBK 提交的回帖内容", replies)
+	}
+	if got := app.state.Snapshot().Steps["checkin"].Output["summary"]; got != "签到成功，获得 金币 5" {
+		t.Errorf("签到结果 = %q", got)
+	}
+}
+
+func TestHTTPDriverCheckInNeedsReply(t *testing.T) {
+	forum := newFakeForum(t)
+	// 第一次回帖后论坛仍要求先回帖，重试时必须重新回帖才能签到
+	forum.SetRequiredReplies(2)
+	app, _ := newHTTPTestApp(t, forum)
+
+	err := app.runPipeline()
+	if !errors.Is(err, ErrReplyRequired) {
+		t.Fatalf("runPipeline 错误 = %v，期望 ErrReplyRequired", err)
+	}
+	state := app.state.Snapshot()
+	for _, name := range []string{"pick_post", "reply"} {
+		if _, ok := state.Steps[name]; ok {
+			t.Errorf("步骤 %s 的结果未被清除: %+v", name, state.Steps[name])
+		}
+	}
+
+	if err := app.runPipeline(); err != nil {
+		t.Fatalf("重试失败: %v", err)
+	}
+	if !app.pipeline.Done(app.state.Snapshot()) {
+		t.Error("重试后任务未完成")
+	}
+	if len(forum.Replies()) != 2 || !forum.Signed() {
+		t.Errorf("回帖 %v，签到 %v，期望重新回帖后签到成功", forum.Replies(), forum.Signed())
+	}
+}
+
+func TestHTTPDriverReplyRejected(t *testing.T) {
+	forum := newFakeForum(t)
+	forum.SetReplyNotice("发帖间隔不能少于 30 秒")
+	app, _ := newHTTPTestApp(t, forum)
+
+	driver := NewHTTPDriver(app.cfg, app.session)
+	if err := driver.Login(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := driver.Reply("read.php?tid=2001")
+	if err == nil || !strings.Contains(err.Error(), "发帖间隔不能少于 30 秒") {
+		t.Errorf("Reply 错误 = %v，期望包含论坛提示", err)
+	}
+}
+
+func TestHTTPDriverReplyRejectedWithEarlierReply(t *testing.T) {
+	forum := newFakeForum(t)
+	app, _ := newHTTPTestApp(t, forum)
+
+	driver := NewHTTPDriver(app.cfg, app.session)
+	if err := driver.Login(); err != nil {
+		t.Fatal(err)
+	}
+	// 之前已有相同内容的回复，本次被拒绝的回帖不能被当成成功
+	if _, err := driver.Reply("read.php?tid=2001"); err != nil {
+		t.Fatal(err)
+	}
+	forum.SetReplyNotice("发帖间隔不能少于 30 秒")
+	_, err := driver.Reply("read.php?tid=2001")
+	if err == nil || !strings.Contains(err.Error(), "发帖间隔不能少于 30 秒") {
+		t.Errorf("Reply 错误 = %v，期望包含论坛提示", err)
+	}
+}
+
+func TestHTTPDriverReplyUnverified(t *testing.T) {
+	forum := newFakeForum(t)
+	forum.SetHideReplies(true)
+	app, recorder := newHTTPTestApp(t, forum)
+
+	// 找不到回复也没有论坛提示时不能重试回帖，继续签到
+	if err := app.runPipeline(); err != nil {
+		t.Fatalf("runPipeline 返回错误: %v", err)
+	}
+	if len(forum.Replies()) != 1 || !forum.Signed() {
+		t.Errorf("回帖 %v，签到 %v，期望只回帖一次并完成签到", forum.Replies(), forum.Signed())
+	}
+	messages := recorder.Messages()
+	if len(messages) != 1 || !strings.Contains(messages[0].Text, "请手动检查") {
+		t.Errorf("通知 %+v，期望提醒手动检查回帖", messages)
+	}
+}
+
+func TestHTTPDriverVerifyDoesNotPost(t *testing.T) {
+	forum := newFakeForum(t)
+	app, _ := newHTTPTestApp(t, forum)
+
+	if code := runLogin(app); code != exitOK {
+		t.Fatalf("login 退出码 = %d", code)
+	}
+	reports := NewHTTPDriver(app.cfg, app.session).Verify()
+	if !verifyPassed(reports) || len(reports) != 5 {
+		t.Errorf("检查未通过:\n%s", formatVerifyReport(reports))
+	}
+	if len(forum.Replies()) != 0 || forum.Signed() || forum.Logins() != 1 {
+		t.Errorf("verify 不应提交表单：回帖 %v，签到 %v，登录 %d 次", forum.Replies(), forum.Signed(), forum.Logins())
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// PHPWindDriver 通过浏览器操作 PHPWind 论坛，页面元素全部来自配置的选择器
+type PHPWindDriver struct {
+	browser *Browser
+	cfg     *Config
+	sel     SiteProfile
+	session *SessionStore
+}
+
+// NewPHPWindDriver 使用已启动的浏览器创建驱动，驱动关闭时一并关闭浏览器
+func NewPHPWindDriver(cfg *Config, browser *Browser, session *SessionStore) *PHPWindDriver {
+	return &PHPWindDriver{browser: browser, cfg: cfg, sel: cfg.Selectors, session: session}
+}
+
+// Close 关闭浏览器
+func (d *PHPWindDriver) Close() {
+	d.browser.Close()
+}
+
+// Snapshot 获取当前页面的地址、HTML 和整页截图
+func (d *PHPWindDriver) Snapshot() (PageSnapshot, error) {
+	return d.browser.Snapshot()
+}
+
+// pageURL 拼出论坛页面的完整地址
+func (d *PHPWindDriver) pageURL(section string) string {
+	return d.cfg.Site.BaseURL + section
+}
+
+// Open 打开回帖页并等待页头加载
+func (d *PHPWindDriver) Open() error {
+	if err := d.browser.NavigateTo(d.pageURL(d.cfg.Site.ReplySection)); err != nil {
+		return err
+	}
+	return d.browser.WaitForElement(d.sel.Header.Selector)
+}
+
+// IsLoggedIn 打开回帖页，页头中出现未登录文字且没有已登录文字时视为未登录
+func (d *PHPWindDriver) IsLoggedIn() (bool, error) {
+	if err := d.browser.NavigateTo(d.pageURL(d.cfg.Site.ReplySection)); err != nil {
+		return false, err
+	}
+	return d.headerLoggedIn()
+}
+
+// headerLoggedIn 根据当前页面的页头判断是否已登录
+func (d *PHPWindDriver) headerLoggedIn() (bool, error) {
+	header := d.sel.Header
+	if err := d.browser.WaitForElement(header.Selector); err != nil {
+		return false, err
+	}
+	// 页面中有多个页头时取第一个
+	headerHTML, err := d.browser.GetHTML(header.Selector)
+	if err != nil {
+		return false, err
+	}
+	if strings.Contains(headerHTML, header.LoggedIn) {
+		return true, nil
+	}
+	return header.LoggedOut == "" || !strings.Contains(headerHTML, header.LoggedOut), nil
+}
+
+// Login 优先加载保存的 cookies，仍未登录时提交登录表单
+func (d *PHPWindDriver) Login() error {
+	loggedIn, err := d.IsLoggedIn()
+	if err != nil {
+		return err
+	}
+	if loggedIn {
+		log.Printf("检测到已登录状态")
+		return nil
+	}
+
+	loggedIn, err = d.restoreCookies()
+	if err != nil {
+		return err
+	}
+	if loggedIn {
+		log.Printf("使用已有的 cookies 登录成功")
+		return nil
+	}
+
+	if err := d.submitLogin(); err != nil {
+		return err
+	}
+	d.saveSession()
+	return nil
+}
+
+// Relogin 清除浏览器中的 cookies 后提交登录表单，成功后保存新的 cookies
+func (d *PHPWindDriver) Relogin() error {
+	if err := d.browser.ClearCookies(); err != nil {
+		return err
+	}
+	if err := d.submitLogin(); err != nil {
+		return err
+	}
+	d.saveSession()
+	return nil
+}
+
+// saveSession 保存登录后的 cookies，保存失败只影响下次是否需要重新登录，不影响本次执行
+func (d *PHPWindDriver) saveSession() {
+	cookies, err := d.browser.Cookies()
+	if err == nil {
+		err = d.session.Save(cookies)
+	}
+	if err != nil {
+		log.Printf("登录成功，但 cookies 保存失败: %v", err)
+		return
+	}
+	log.Printf("登录成功，cookies 已保存到 %s", d.session.Path())
+}
+
+// restoreCookies 加载未过期的 cookies 并刷新当前页面，根据页头确认之后是否已登录
+func (d *PHPWindDriver) restoreCookies() (bool, error) {
+	cookies, err := d.session.Load()
+	if err != nil {
+		log.Printf("%v，需要重新登录", err)
+		return false, nil
+	}
+	if len(cookies) == 0 {
+		return false, nil
+	}
+	if err := d.browser.SetCookies(cookies); err != nil {
+		return false, err
+	}
+	loggedIn, err := d.headerLoggedIn()
+	if err == nil && !loggedIn {
+		log.Printf("已保存的 cookies 已失效，需要重新登录")
+	}
+	return loggedIn, err
+}
+
+// submitLogin 填写登录表单中：用户名、密码、安全问题、答案
+func (d *PHPWindDriver) submitLogin() error {
+	form := d.sel.Login
+	account := d.cfg.Account
+	if err := d.browser.NavigateTo(d.pageURL(d.cfg.Site.LoginSection)); err != nil {
+		return err
+	}
+	// 等待登录表单区域加载
+	if err := d.browser.WaitForElement(form.Form); err != nil {
+		return err
+	}
+
+	actions := []chromedp.Action{
+		chromedp.SendKeys(form.Username, account.Username),
+		chromedp.SendKeys(form.Password, account.Password),
+	}
+	if form.Question != "" {
+		actions = append(actions, chromedp.SetValue(form.Question, account.SecurityQuestion, chromedp.BySearch))
+	}
+	if form.Answer != "" && account.SecurityAnswer != "" {
+		actions = append(actions, chromedp.SendKeys(form.Answer, account.SecurityAnswer))
+	}
+	actions = append(actions,
+		chromedp.Click(form.Submit),
+		// 登录后等待页面切换，等待页头出现
+		chromedp.WaitVisible(d.sel.Header.Selector, chromedp.ByQuery),
+		// 小等待确保登录后的 cookie 已经同步
+		chromedp.Sleep(2*time.Second),
+	)
+	if err := d.browser.Execute(actions...); err != nil {
+		log.Printf("登陆操作出错：%v", err)
+		return err
+	}
+	return nil
+}
+
+// PickThread 打开回帖版块，取广告注释后第一个符合条件的帖子
+func (d *PHPWindDriver) PickThread() (title string, href string, err error) {
+	if err = d.browser.NavigateTo(d.pageURL(d.cfg.Site.ReplySection)); err != nil {
+		log.Printf("导航回帖页失败: %v", err)
+		return
+	}
+	if err = d.browser.WaitForElement(d.sel.Thread.List); err != nil {
+		log.Printf("等待元素失败: %v", err)
+		return
+	}
+	htmlContent, err := d.browser.GetHTML("body")
+	if err != nil {
+		log.Printf("获取HTML失败: %v", err)
+		return
+	}
+	return parseFirstPost(htmlContent, d.sel.Thread)
+}
+
+// Reply 打开帖子最后一页，输入回帖内容并提交，之后确认帖子中出现了本账号新的回复
+func (d *PHPWindDriver) Reply(href string) (ReplyResult, error) {
+	form := d.sel.Reply
+	// 在最后一页回复，提交前后都在最后一页查找，才能区分新旧回复
+	if err := d.browser.NavigateTo(d.pageURL(href + form.LastPage)); err != nil {
+		log.Printf("打开帖子失败: %v", err)
+		return ReplyResult{}, err
+	}
+	// 等待回帖区域加载
+	if err := d.browser.WaitForElement(form.Textarea); err != nil {
+		log.Printf("等待回帖区域加载失败: %v", err)
+		return ReplyResult{}, err
+	}
+
+	// 随机选择回帖内容，并记录页面中已有的相同回复
+	replyContent := randomReplyContent()
+	htmlContent, err := d.browser.GetHTML("body")
+	if err != nil {
+		log.Printf("读取帖子内容失败: %v", err)
+		return ReplyResult{}, err
+	}
+	before, err := parseOwnReplies(htmlContent, form, d.cfg.Account.Username, replyContent)
+	if err != nil {
+		return ReplyResult{}, err
+	}
+	if err := d.browser.Input(form.Textarea, replyContent); err != nil {
+		log.Printf("输入回帖内容失败: %v", err)
+		return ReplyResult{}, err
+	}
+	if err := d.browser.Click(form.Submit); err != nil {
+		log.Printf("点击回帖按钮失败: %v", err)
+		return ReplyResult{}, err
+	}
+	// 等待3秒，让回帖提交完成
+	time.Sleep(3 * time.Second)
+
+	result, err := d.replyResult(replyContent, before)
+	if err != nil {
+		return result, err
+	}
+	if !result.Posted && form.LastPage != "" {
+		// 提交后停留在提示页时，打开帖子最后一页再查找，提示页的文字比帖子页更有参考价值
+		notice := result.Message
+		if err := d.browser.NavigateTo(d.pageURL(href + form.LastPage)); err != nil {
+			log.Printf("打开帖子最后一页失败: %v", err)
+		} else if result, err = d.replyResult(replyContent, before); err != nil {
+			return result, err
+		}
+		if notice != "" {
+			result.Message = notice
+		}
+	}
+
+	if !result.Posted {
+		if result.Message != "" {
+			return result, fmt.Errorf("回帖未成功，论坛提示: %s", result.Message)
+		}
+		// 没有论坛提示时回帖可能已经成功，只是页面与选择器不符，重新提交会重复回帖
+		log.Printf("回帖后未在帖子中找到本账号新的回复，也没有论坛提示，无法确认回帖结果")
+	}
+	return result, nil
+}
+
+// replyResult 读取当前页面，与提交前的 before 条相同回复比较，检查回帖是否成功
+func (d *PHPWindDriver) replyResult(content string, before int) (ReplyResult, error) {
+	htmlContent, err := d.browser.GetHTML("body")
+	if err != nil {
+		log.Printf("获取回帖结果失败: %v", err)
+		return ReplyResult{Content: content}, err
+	}
+	return parseReplyResult(htmlContent, d.sel.Reply, d.cfg.Account.Username, content, before)
+}
+
+// checkInNotice 读取签到页的提示文本，元素不存在时返回空字符串
+func (d *PHPWindDriver) checkInNotice() (string, error) {
+	sel, err := json.Marshal(d.sel.CheckIn.Notice)
+	if err != nil {
+		return "", err
+	}
+	var text string
+	err = d.browser.Execute(chromedp.Evaluate(
+		`(function(){var el=document.querySelector(`+string(sel)+`);return el?el.innerText.trim():"";})()`,
+		&text,
+	))
+	return text, err
+}
+
+// CheckIn 到签到页面签到并对结果分类。页面已提示今天已签到、需要回帖或未登录时不再点击签到按钮
+func (d *PHPWindDriver) CheckIn() (CheckInResult, error) {
+	form := d.sel.CheckIn
+	if err := d.browser.NavigateTo(d.pageURL(d.cfg.Site.CheckInSection)); err != nil {
+		return CheckInResult{}, err
+	}
+	// 今天已签到时页面不会出现签到按钮，先检查提示文本
+	if notice, err := d.checkInNotice(); err == nil {
+		if result := classifyCheckIn(notice); result.Status != CheckInUnknown && result.Status != CheckInSigned {
+			log.Printf("%s 签到页提示：%s", time.Now().Format("2006-01-02"), notice)
+			return result, nil
+		}
+	}
+	// 等待签到按钮加载
+	if err := d.browser.WaitForElement(form.Submit); err != nil {
+		return CheckInResult{}, err
+	}
+
+	var actions []chromedp.Action
+	// 随机选择一个心情
+	if form.Mood != "" {
+		selected := form.Moods[rand.IntN(len(form.Moods))]
+		actions = append(actions, chromedp.Click(form.Mood+`[value="`+selected+`"]`, chromedp.ByQuery))
+	}
+	var resultText string
+	actions = append(actions,
+		chromedp.Click(form.Submit, chromedp.ByQuery),
+		// 等待签到结果文本加载
+		chromedp.Text(form.Notice, &resultText, chromedp.ByQuery),
+	)
+	if err := d.browser.Execute(actions...); err != nil {
+		log.Printf("签到操作出错：%v", err)
+		return CheckInResult{}, err
+	}
+	log.Printf("%s 签到结果：%s", time.Now().Format("2006-01-02"), resultText)
+	return classifyCheckIn(resultText), nil
+}
+
+// FetchProfile 打开个人资料页并解析积分
+func (d *PHPWindDriver) FetchProfile() (UserPoints, error) {
+	if err := d.browser.NavigateTo(d.pageURL(d.cfg.Site.UserInfoSection)); err != nil {
+		return UserPoints{}, err
+	}
+
+	time.Sleep(5 * time.Second)
+
+	// 获取积分区域的HTML
+	infoHTML, err := d.browser.GetHTML(d.sel.Profile.Container)
+	if err != nil {
+		log.Printf("获取用户信息区域HTML失败: %v", err)
+		return UserPoints{}, err
+	}
+
+	points, err := parseUserInfo(infoHTML, d.sel.Profile)
+	if err != nil {
+		log.Printf("解析用户信息HTML失败: %v", err)
+		return UserPoints{}, err
+	}
+	if len(points.Raw) > 0 {
+		log.Printf("成功获取用户积分信息: %+v", points.Raw)
+	}
+	return points, nil
+}
+
+// selectorSpec 待检查的选择器，opts 为空时按 chromedp 默认方式查找（支持 XPath）
+type selectorSpec struct {
+	name     string
+	selector string
+	opts     []chromedp.QueryOption
+	optional bool
+}
+
+// inspect 检查当前页面中的选择器，只读取页面，不点击也不提交
+func (d *PHPWindDriver) inspect(report *PageReport, specs []selectorSpec) {
+	for _, spec := range specs {
+		if spec.selector == "" {
+			continue
+		}
+		found, visible, err := d.browser.Inspect(spec.selector, spec.opts...)
+		if err != nil {
+			report.Err = fmt.Errorf("检查 %s 失败: %w", spec.name, err)
+			return
+		}
+		report.Checks = append(report.Checks, SelectorCheck{
+			Name:     spec.name,
+			Selector: spec.selector,
+			Found:    found,
+			Visible:  visible,
+			Optional: spec.optional,
+		})
+	}
+}
+
+// Verify 依次打开流程中的每个页面并检查选择器是否存在且可见。
+// 只使用已保存的 cookies 登录，不会提交登录表单和回帖表单，也不会点击签到按钮
+func (d *PHPWindDriver) Verify() []PageReport {
+	byQuery := []chromedp.QueryOption{chromedp.ByQuery}
+	sel := d.sel
+	var reports []PageReport
+
+	// 登录页，在加载 cookies 之前检查，此时应显示登录表单
+	login := PageReport{Page: "登录页", URL: d.pageURL(d.cfg.Site.LoginSection)}
+	if login.Err = d.browser.NavigateTo(login.URL); login.Err == nil {
+		d.inspect(&login, []selectorSpec{
+			{name: "header.selector", selector: sel.Header.Selector, opts: byQuery},
+			{name: "login.form", selector: sel.Login.Form, opts: byQuery},
+			{name: "login.username", selector: sel.Login.Username},
+			{name: "login.password", selector: sel.Login.Password},
+			{name: "login.question", selector: sel.Login.Question},
+			{name: "login.answer", selector: sel.Login.Answer},
+			{name: "login.submit", selector: sel.Login.Submit},
+		})
+	}
+	reports = append(reports, login)
+
+	// 回帖版块，先用保存的 cookies 登录
+	list := PageReport{Page: "回帖版块", URL: d.pageURL(d.cfg.Site.ReplySection)}
+	if list.Err = d.browser.NavigateTo(list.URL); list.Err != nil {
+		return append(reports, list)
+	}
+	loggedIn, err := d.restoreCookies()
+	switch {
+	case err != nil:
+		list.Err = fmt.Errorf("加载 cookies 失败: %w", err)
+	case !loggedIn:
+		list.Err = errors.New("没有可用的 cookies 或 cookies 已失效，请先执行 login 命令")
+	}
+	if list.Err != nil {
+		list.Note = "后续页面需要登录，已跳过"
+		return append(reports, list)
+	}
+	d.inspect(&list, []selectorSpec{
+		{name: "thread.list", selector: sel.Thread.List, opts: byQuery},
+		{name: "thread.body", selector: sel.Thread.Body, opts: byQuery},
+		{name: "thread.row", selector: sel.Thread.Row, opts: byQuery},
+		{name: "thread.link", selector: sel.Thread.Link, opts: byQuery},
+	})
+	var title, href string
+	if list.Err == nil {
+		var htmlContent string
+		if htmlContent, list.Err = d.browser.GetHTML("body"); list.Err == nil {
+			if title, href, list.Err = parseFirstPost(htmlContent, sel.Thread); list.Err == nil {
+				list.Note = "将回复的帖子: " + title
+			}
+		}
+	}
+	reports = append(reports, list)
+
+	// 帖子页，只检查回复表单，不输入也不提交
+	thread := PageReport{Page: "帖子页"}
+	if href == "" {
+		thread.Err = errors.New("没有可检查的帖子")
+	} else {
+		thread.URL = d.pageURL(href)
+		if thread.Err = d.browser.NavigateTo(thread.URL); thread.Err == nil {
+			d.inspect(&thread, []selectorSpec{
+				{name: "reply.textarea", selector: sel.Reply.Textarea, opts: byQuery},
+				{name: "reply.submit", selector: sel.Reply.Submit, opts: byQuery},
+			})
+		}
+	}
+	reports = append(reports, thread)
+
+	// 签到页，今天已签到时不会出现签到按钮
+	checkIn := PageReport{Page: "签到页", URL: d.pageURL(d.cfg.Site.CheckInSection)}
+	if checkIn.Err = d.browser.NavigateTo(checkIn.URL); checkIn.Err == nil {
+		if notice, err := d.checkInNotice(); err == nil && isAlreadyCheckedIn(notice) {
+			checkIn.Note = "今天已签到，未检查签到按钮: " + notice
+			d.inspect(&checkIn, []selectorSpec{
+				{name: "checkin.notice", selector: sel.CheckIn.Notice, opts: byQuery},
+			})
+		} else {
+			d.inspect(&checkIn, []selectorSpec{
+				{name: "checkin.mood", selector: sel.CheckIn.Mood, opts: byQuery},
+				{name: "checkin.submit", selector: sel.CheckIn.Submit, opts: byQuery},
+				// 签到前不一定有提示文本
+				{name: "checkin.notice", selector: sel.CheckIn.Notice, opts: byQuery, optional: true},
+			})
+		}
+	}
+	reports = append(reports, checkIn)
+
+	// 个人资料页，同时确认能解析出积分
+	profile := PageReport{Page: "个人资料页", URL: d.pageURL(d.cfg.Site.UserInfoSection)}
+	if profile.Err = d.browser.NavigateTo(profile.URL); profile.Err == nil {
+		d.inspect(&profile, []selectorSpec{
+			{name: "profile.container", selector: sel.Profile.Container, opts: byQuery},
+			{name: "profile.rows", selector: sel.Profile.Rows, opts: byQuery},
+		})
+	}
+	if profile.OK() {
+		var infoHTML string
+		var points UserPoints
+		if infoHTML, profile.Err = d.browser.GetHTML(sel.Profile.Container); profile.Err == nil {
+			if points, profile.Err = parseUserInfo(infoHTML, sel.Profile); profile.Err == nil {
+				if len(points.Raw) == 0 {
+					profile.Err = errors.New("未解析到积分，请检查 profile.key 和 profile.value")
+				} else {
+					profile.Note = "积分: " + points.String()
+				}
+			}
+		}
+	}
+	return append(reports, profile)
+}
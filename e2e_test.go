@@ -0,0 +1,243 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingNotifier 记录发送的消息
+type recordingNotifier struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (n *recordingNotifier) Name() string { return "recording" }
+
+func (n *recordingNotifier) Notify(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func (n *recordingNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.messages...)
+}
+
+// findChrome 查找本地 Chrome/Chromium，找不到时跳过测试
+func findChrome(t *testing.T) string {
+	t.Helper()
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		return path
+	}
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	t.Skip("未找到 Chrome/Chromium，跳过端到端测试")
+	return ""
+}
+
+// newTestApp 创建通过 Chrome 连接模拟论坛的应用，状态和 cookies 都写入临时目录
+func newTestApp(t *testing.T, forum *fakeForum) (*App, *recordingNotifier) {
+	t.Helper()
+	chrome := findChrome(t)
+	return newTestAppWith(t, forum, func(cfg *Config) {
+		cfg.Browser.Headless = true
+		cfg.Browser.ChromePath = chrome
+	})
+}
+
+// newHTTPTestApp 创建使用 HTTP 驱动连接模拟论坛的应用，不需要 Chrome
+func newHTTPTestApp(t *testing.T, forum *fakeForum) (*App, *recordingNotifier) {
+	t.Helper()
+	return newTestAppWith(t, forum, func(cfg *Config) {
+		cfg.Site.Driver = DriverHTTP
+	})
+}
+
+// newTestAppWith 创建连接模拟论坛的应用，configure 修改驱动相关的配置
+func newTestAppWith(t *testing.T, forum *fakeForum, configure func(cfg *Config)) (*App, *recordingNotifier) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	cfg := defaultConfig()
+	forum.SiteConfig(cfg)
+	configure(cfg)
+	cfg.Storage.StateFile = filepath.Join(dir, "state.json")
+	cfg.Storage.PointsHistoryFile = filepath.Join(dir, "points_history.csv")
+
+	app := NewApp(cfg)
+	recorder := &recordingNotifier{}
+	app.notifier = recorder
+	t.Cleanup(func() {
+		app.mu.Lock()
+		if app.retryTimer != nil {
+			app.retryTimer.Stop()
+		}
+		app.mu.Unlock()
+	})
+	return app, recorder
+}
+
+func TestFakeForumPages(t *testing.T) {
+	forum := newFakeForum(t)
+	client := &http.Client{}
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := client.Get(forum.URL + "/" + path)
+		if err != nil {
+			t.Fatalf("请求 %s 失败: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	_, body := get("thread.php?fid=57")
+	title, href, err := parseFirstPost(body, defaultSiteProfile().Thread)
+	if err != nil || title != "新片速递 第一集" || href != "read.php?tid=2001" {
+		t.Errorf("parseFirstPost = %q, %q, %v", title, href, err)
+	}
+	if !strings.Contains(body, "登录") || strings.Contains(body, "退出") {
+		t.Error("未登录时页头应显示登录")
+	}
+
+	_, body = get("u.php?action=show")
+	if !strings.Contains(body, "您还没有登录") {
+		t.Error("未登录时个人资料页应提示登录")
+	}
+
+	forum.SetError("/hack.php", http.StatusBadGateway)
+	if code, _ := get("hack.php?H_name=qiandao"); code != http.StatusBadGateway {
+		t.Errorf("注入错误后状态码 = %d", code)
+	}
+	forum.SetError("/hack.php", 0)
+
+	forum.SetDelay("/index.php", 200*time.Millisecond)
+	start := time.Now()
+	get("index.php")
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("注入延迟后响应耗时 %v", elapsed)
+	}
+}
+
+func TestExecuteTaskEndToEnd(t *testing.T) {
+	forum := newFakeForum(t)
+	app, recorder := newTestApp(t, forum)
+
+	app.executeTask()
+
+	state := app.state.Snapshot()
+	if !app.pipeline.Done(state) {
+		t.Fatalf("任务未完成，最近失败: %+v", state.LastStepError())
+	}
+	if forum.Logins() != 1 {
+		t.Errorf("登录次数 = %d，期望 1", forum.Logins())
+	}
+	if replies := forum.Replies(); len(replies) != 1 {
+		t.Errorf("回帖 %v，期望 1 条", replies)
+	}
+	if !forum.Signed() {
+		t.Error("论坛未收到签到")
+	}
+	if _, err := os.Stat("cookies/data.json"); err != nil {
+		t.Errorf("cookies 未保存: %v", err)
+	}
+
+	messages := recorder.Messages()
+	if len(messages) != 1 || messages[0].Kind != MessageSuccess {
+		t.Fatalf("通知 %+v，期望一条成功通知", messages)
+	}
+	for _, want := range []string{"新片速递 第一集", "签到成功，获得 金币 5", "金币: 105"} {
+		if !strings.Contains(messages[0].Text, want) {
+			t.Errorf("通知内容缺少 %q:\n%s", want, messages[0].Text)
+		}
+	}
+}
+
+func TestExecuteTaskAlreadySigned(t *testing.T) {
+	forum := newFakeForum(t)
+	forum.SetSigned(true)
+	app, recorder := newTestApp(t, forum)
+
+	app.executeTask()
+
+	state := app.state.Snapshot()
+	if !app.pipeline.Done(state) {
+		t.Fatalf("任务未完成，最近失败: %+v", state.LastStepError())
+	}
+	if result := state.Steps["checkin"].Output["result"]; !isAlreadyCheckedIn(result) {
+		t.Errorf("签到结果 = %q，期望已签到提示", result)
+	}
+	if status := state.Steps["checkin"].Output["status"]; status != CheckInAlreadySigned.String() {
+		t.Errorf("签到分类 = %q，期望 %s", status, CheckInAlreadySigned)
+	}
+	if len(recorder.Messages()) != 1 {
+		t.Errorf("通知 %+v，期望一条", recorder.Messages())
+	}
+}
+
+func TestExecuteTaskReplyRejected(t *testing.T) {
+	forum := newFakeForum(t)
+	forum.SetReplyNotice("发帖间隔不能少于 30 秒")
+	app, recorder := newTestApp(t, forum)
+
+	app.executeTask()
+
+	state := app.state.Snapshot()
+	if state.Steps["reply"].Success {
+		t.Fatal("论坛拒绝回帖时回帖步骤不应成功")
+	}
+	messages := recorder.Messages()
+	if len(messages) != 1 || messages[0].Kind != MessageFailure {
+		t.Fatalf("通知 %+v，期望一条失败通知", messages)
+	}
+	if !strings.Contains(messages[0].Text, "发帖间隔不能少于 30 秒") {
+		t.Errorf("失败通知应包含论坛提示:\n%s", messages[0].Text)
+	}
+}
+
+func TestVerifyDoesNotPost(t *testing.T) {
+	forum := newFakeForum(t)
+	app, _ := newTestApp(t, forum)
+
+	// 先登录保存 cookies，verify 只使用已保存的 cookies
+	if code := runLogin(app); code != exitOK {
+		t.Fatalf("login 退出码 = %d", code)
+	}
+	driver, err := app.newDriver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Close()
+
+	reports := driver.Verify()
+	if !verifyPassed(reports) {
+		t.Errorf("检查未通过:\n%s", formatVerifyReport(reports))
+	}
+	if len(reports) != 5 {
+		t.Errorf("检查了 %d 个页面，期望 5 个", len(reports))
+	}
+	if replies := forum.Replies(); len(replies) != 0 {
+		t.Errorf("verify 不应回帖，实际回复 %v", replies)
+	}
+	if forum.Signed() {
+		t.Error("verify 不应签到")
+	}
+	if forum.Logins() != 1 {
+		t.Errorf("登录次数 = %d，verify 不应提交登录表单", forum.Logins())
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// fakeForum 模拟程序用到的 PHPWind 页面，可以按路径注入错误和延迟，
+// 也可以强制显示未登录或今天已签到的页面
+type fakeForum struct {
+	*httptest.Server
+
+	Username string
+	Password string
+
+	mu sync.Mutex
+	// errors 按路径返回的错误状态码
+	errors map[string]int
+	// delays 按路径增加的响应延迟
+	delays map[string]time.Duration
+	// loggedOut 为 true 时即使带有登录 cookie 也显示未登录的页头
+	loggedOut bool
+	signed    bool
+	replies   []string
+	logins    int
+	// replyNotice 不为空时拒绝回帖并显示该提示，模拟发帖间隔限制等
+	replyNotice string
+	// hideReplies 为 true 时接受回帖但不在帖子中显示，也没有提示，模拟无法确认的回帖结果
+	hideReplies bool
+	// gbk 为 true 时页面按 GBK 编码输出，表单也按 GBK 解码，模拟常见的 PHPWind 论坛
+	gbk bool
+	// requiredReplies 签到前至少需要的回帖数，不足时提示先回帖
+	requiredReplies int
+}
+
+const fakeSessionCookie = "winduser"
+
+// newFakeForum 启动模拟论坛，测试结束时自动关闭
+func newFakeForum(t *testing.T) *fakeForum {
+	t.Helper()
+	f := &fakeForum{
+		Username: "testuser",
+		Password: "secret",
+		errors:   make(map[string]int),
+		delays:   make(map[string]time.Duration),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.php", f.handleIndex)
+	mux.HandleFunc("/login.php", f.handleLogin)
+	mux.HandleFunc("/thread.php", f.handleThreadList)
+	mux.HandleFunc("/read.php", f.handleThread)
+	mux.HandleFunc("/post.php", f.handlePost)
+	mux.HandleFunc("/hack.php", f.handleCheckIn)
+	mux.HandleFunc("/u.php", f.handleProfile)
+
+	f.Server = httptest.NewServer(f.fault(mux))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// SiteConfig 返回指向模拟论坛的站点配置
+func (f *fakeForum) SiteConfig(cfg *Config) {
+	cfg.Site.BaseURL = f.URL + "/"
+	cfg.Account.Username = f.Username
+	cfg.Account.Password = f.Password
+	cfg.Account.SecurityQuestion = "0"
+}
+
+// SetError 让指定路径返回错误状态码，code 为 0 时取消
+func (f *fakeForum) SetError(path string, code int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[path] = code
+}
+
+// SetDelay 让指定路径延迟响应
+func (f *fakeForum) SetDelay(path string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delays[path] = d
+}
+
+// SetLoggedOut 强制所有页面显示未登录的页头
+func (f *fakeForum) SetLoggedOut(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loggedOut = v
+}
+
+// SetSigned 设置今天是否已经签到
+func (f *fakeForum) SetSigned(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.signed = v
+}
+
+// SetReplyNotice 让之后的回帖被拒绝并显示 notice，为空时恢复正常
+func (f *fakeForum) SetReplyNotice(notice string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replyNotice = notice
+}
+
+// SetHideReplies 设置是否在帖子中隐藏收到的回帖
+func (f *fakeForum) SetHideReplies(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hideReplies = v
+}
+
+// SetGBK 设置页面和表单是否使用 GBK 编码
+func (f *fakeForum) SetGBK(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gbk = v
+}
+
+// SetRequiredReplies 设置签到前至少需要的回帖数
+func (f *fakeForum) SetRequiredReplies(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requiredReplies = n
+}
+
+// Signed 返回今天是否已经签到
+func (f *fakeForum) Signed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.signed
+}
+
+// Replies 返回收到的回帖内容
+func (f *fakeForum) Replies() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.replies...)
+}
+
+// Logins 返回表单登录成功的次数
+func (f *fakeForum) Logins() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins
+}
+
+// fault 按配置注入延迟和错误
+func (f *fakeForum) fault(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		delay := f.delays[r.URL.Path]
+		code := f.errors[r.URL.Path]
+		f.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if code != 0 {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loggedIn 根据 cookie 判断请求是否已登录
+func (f *fakeForum) loggedIn(r *http.Request) bool {
+	f.mu.Lock()
+	loggedOut := f.loggedOut
+	f.mu.Unlock()
+	if loggedOut {
+		return false
+	}
+	c, err := r.Cookie(fakeSessionCookie)
+	return err == nil && c.Value == url.QueryEscape(f.Username)
+}
+
+// page 输出带页头的完整页面
+func (f *fakeForum) page(w http.ResponseWriter, r *http.Request, title, body string) {
+	header := `<a href="login.php">登录</a> | <a href="register.php">注册</a>`
+	if f.loggedIn(r) {
+		header = fmt.Sprintf(`<a href="u.php">%s</a> | <a href="login.php?action=quit">退出</a>`, html.EscapeString(f.Username))
+	}
+	f.mu.Lock()
+	gbk := f.gbk
+	f.mu.Unlock()
+	charset := "utf-8"
+	if gbk {
+		charset = "gbk"
+	}
+	out := fmt.Sprintf(`<!DOCTYPE html>
+<html><head><meta charset="%s"><title>%s - 2048核基地</title></head>
+<body>
+<div id="header"><div class="header_up_sign">%s</div></div>
+%s
+</body></html>`, charset, title, header, body)
+	if gbk {
+		out, _ = simplifiedchinese.GBK.NewEncoder().String(out)
+	}
+	w.Header().Set("Content-Type", "text/html; charset="+charset)
+	io.WriteString(w, out)
+}
+
+// formValue 返回表单字段，GBK 模式下按 GBK 解码
+func (f *fakeForum) formValue(r *http.Request, name string) string {
+	r.ParseForm()
+	value := r.PostForm.Get(name)
+	f.mu.Lock()
+	gbk := f.gbk
+	f.mu.Unlock()
+	if gbk {
+		value, _ = simplifiedchinese.GBK.NewDecoder().String(value)
+	}
+	return value
+}
+
+// requireLogin 未登录时输出提示页面并返回 false
+func (f *fakeForum) requireLogin(w http.ResponseWriter, r *http.Request) bool {
+	if f.loggedIn(r) {
+		return true
+	}
+	f.page(w, r, "提示信息", `<div id="main"><div class="f14">您还没有登录或注册，暂时不能使用此功能</div></div>`)
+	return false
+}
+
+func (f *fakeForum) handleIndex(w http.ResponseWriter, r *http.Request) {
+	f.page(w, r, "首页", `<div id="main">首页</div>`)
+}
+
+func (f *fakeForum) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if f.formValue(r, "pwuser") != f.Username || f.formValue(r, "pwpwd") != f.Password {
+			f.page(w, r, "提示信息", `<div id="main"><div class="f14">用户名或密码错误</div></div>`)
+			return
+		}
+		f.mu.Lock()
+		f.logins++
+		f.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{
+			Name:    fakeSessionCookie,
+			Value:   url.QueryEscape(f.Username),
+			Path:    "/",
+			Expires: time.Now().Add(30 * 24 * time.Hour),
+		})
+		http.Redirect(w, r, "index.php", http.StatusFound)
+		return
+	}
+
+	f.page(w, r, "登录", `<div id="main"><form method="post" action="login.php"><div><table><tbody><tr><td>
+<div class="cc p10 regItem">
+	<dl><dt>用户名</dt><dd><input type="text" name="pwuser"></dd></dl>
+	<dl><dt>密码</dt><dd><input type="password" name="pwpwd"></dd></dl>
+	<dl><dt>安全问题</dt><dd><select name="question">
+		<option value="0">无安全问题</option>
+		<option value="4">我的中学校名</option>
+	</select></dd></dl>
+	<dl><dt>答案</dt><dd><input type="text" name="answer"></dd></dl>
+	<dl><dt></dt><dd><input type="checkbox" name="cktime" value="31536000">记住我</dd></dl>
+	<dl><dt></dt><dd><input type="hidden" name="step" value="2"></dd></dl>
+	<dl><dt></dt><dd><input type="submit" class="btn" value="登 录"></dd></dl>
+</div>
+</td></tr></tbody></table></div></form></div>`)
+}
+
+func (f *fakeForum) handleThreadList(w http.ResponseWriter, r *http.Request) {
+	row := func(tid int, title, icon string) string {
+		return fmt.Sprintf(`<tr class="tr3 t_one"><td class="icon tar">%s</td><td class="tal"><a href="read.php?tid=%d" class="subject">%s</a></td></tr>`,
+			icon, tid, title)
+	}
+	pinned := `<img src="images/wind/thread/headtopic_3.gif" alt="置顶帖标志">`
+	f.page(w, r, "新片发布区", `<div id="main"><div class="t z"><table id="ajaxtable">
+<tbody><tr class="tr2"><td colspan="2">文章</td></tr></tbody>
+<tbody>`+
+		row(1001, "【公告】本版发帖规则", pinned)+
+		"<!-- 广告连接 -->"+
+		row(2001, "新片速递 第一集", "")+
+		row(2002, "新片速递 第二集", "")+
+		`</tbody></table></div></div>`)
+}
+
+func (f *fakeForum) handleThread(w http.ResponseWriter, r *http.Request) {
+	tid := r.URL.Query().Get("tid")
+	var sb strings.Builder
+	f.mu.Lock()
+	hidden := f.hideReplies
+	f.mu.Unlock()
+	var shown []string
+	if !hidden {
+		shown = f.Replies()
+	}
+	for _, reply := range shown {
+		// 与真实页面一样，用户信息栏中除用户名外还有等级和积分，正文带有 f14 样式
+		sb.WriteString(fmt.Sprintf(`<div class="t5 t2"><table><tr class="tr1"><th class="r_two">
+<div class="readName b"><a href="u.php?uid=1001">%s</a></div>
+<div class="user-infoWrap2"><ul><li>级别: 新手上路</li><li>金币: 100 枚</li></ul></div>
+</th><th class="r_one"><div class="tpc_content"><div class="f14">%s</div></div></th></tr></table></div>`,
+			html.EscapeString(f.Username), html.EscapeString(reply)))
+	}
+	form := `<div class="f14">您还没有登录，不能回复</div>`
+	if f.loggedIn(r) {
+		form = fmt.Sprintf(`<form method="post" action="post.php?action=reply&tid=%s">
+<textarea id="textarea" name="atc_content"></textarea>
+<input type="submit" class="btn fpbtn" value="回 复">
+</form>`, html.EscapeString(tid))
+	}
+	f.page(w, r, "帖子", fmt.Sprintf(`<div id="main"><h1 id="subject_tpc">帖子 %s</h1>%s%s</div>`,
+		html.EscapeString(tid), sb.String(), form))
+}
+
+func (f *fakeForum) handlePost(w http.ResponseWriter, r *http.Request) {
+	if !f.requireLogin(w, r) {
+		return
+	}
+	content := strings.TrimSpace(f.formValue(r, "atc_content"))
+	if content == "" {
+		f.page(w, r, "提示信息", `<div id="main"><div class="f14">内容不能为空</div></div>`)
+		return
+	}
+	f.mu.Lock()
+	notice := f.replyNotice
+	if notice == "" {
+		f.replies = append(f.replies, content)
+	}
+	f.mu.Unlock()
+	if notice != "" {
+		f.page(w, r, "提示信息", `<div id="main"><div class="f14">`+html.EscapeString(notice)+`</div></div>`)
+		return
+	}
+	http.Redirect(w, r, "read.php?tid="+r.URL.Query().Get("tid"), http.StatusFound)
+}
+
+func (f *fakeForum) handleCheckIn(w http.ResponseWriter, r *http.Request) {
+	if !f.requireLogin(w, r) {
+		return
+	}
+	f.mu.Lock()
+	needsReply := !f.signed && len(f.replies) < f.requiredReplies
+	f.mu.Unlock()
+	if needsReply {
+		f.page(w, r, "签到", `<div id="main"><span class="f14">请先回帖后再来签到</span></div>`)
+		return
+	}
+	if r.Method == http.MethodPost {
+		if f.formValue(r, "qdxq") == "" {
+			f.page(w, r, "签到", `<div id="main"><span class="f14">请选择您的心情</span></div>`)
+			return
+		}
+		f.mu.Lock()
+		already := f.signed
+		f.signed = true
+		f.mu.Unlock()
+		if already {
+			f.page(w, r, "签到", `<div id="main"><span class="f14">您今天已经签到过了，请明天再来</span></div>`)
+			return
+		}
+		f.page(w, r, "签到", `<div id="main"><span class="f14">签到成功，获得 金币 5 枚</span></div>`)
+		return
+	}
+
+	if f.Signed() {
+		f.page(w, r, "签到", `<div id="main"><span class="f14">您今天已经签到过了，请明天再来</span></div>`)
+		return
+	}
+	moods := []string{"kx", "ng", "ym", "wl", "nu", "ch", "fd", "yl", "shuai"}
+	var sb strings.Builder
+	for _, mood := range moods {
+		sb.WriteString(fmt.Sprintf(`<label><input type="radio" name="qdxq" value="%s">%s</label>`, mood, mood))
+	}
+	f.page(w, r, "签到", `<div id="main"><form method="post" action="hack.php?H_name=qiandao&action=qiandao">`+
+		sb.String()+`<input type="submit" id="submit_bbb" value="签到"></form></div>`)
+}
+
+func (f *fakeForum) handleProfile(w http.ResponseWriter, r *http.Request) {
+	if !f.requireLogin(w, r) {
+		return
+	}
+	gold := 100
+	if f.Signed() {
+		gold += 5
+	}
+	f.page(w, r, "个人资料", fmt.Sprintf(`<div id="main"><div class="pwB_uConside_a"><table class="pwB_uTable_a">
+<tr><td>威望</td><th>15</th></tr>
+<tr><td>金币</td><th>%d</th></tr>
+<tr><td>貢獻值</td><th>87</th></tr>
+<tr><td>邀請幣</td><th>2</th></tr>
+</table></div></div>`, gold))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// newHTTPHandler 注册状态与控制接口
+func (a *App) newHTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("ok\n"))
+	})
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, a.currentStatus())
+	})
+
+	mux.HandleFunc("GET /metrics", a.metricsHandler)
+
+	mux.HandleFunc("POST /run", func(w http.ResponseWriter, r *http.Request) {
+		if err := a.triggerTask(); err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"result": "未执行: " + err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"result": "任务已触发"})
+	})
+
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, r *http.Request) {
+		a.pauseScheduler()
+		writeJSON(w, http.StatusOK, a.currentStatus())
+	})
+
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		a.resumeScheduler()
+		writeJSON(w, http.StatusOK, a.currentStatus())
+	})
+
+	return mux
+}
+
+// startHTTPServer 在后台启动可选的状态与控制接口
+func (a *App) startHTTPServer(addr string) {
+	a.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           a.newHTTPHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("HTTP 接口已启动: http://%s", addr)
+		if err := a.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP 接口异常退出: %v", err)
+		}
+	}()
+}
+
+// stopHTTPServer 优雅关闭 HTTP 接口
+func (a *App) stopHTTPServer() {
+	if a.httpServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		log.Printf("关闭 HTTP 接口失败: %v", err)
+	}
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("输出 JSON 响应失败: %v", err)
+	}
+}
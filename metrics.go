@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 步骤耗时直方图的桶（秒），浏览器操作通常在几秒到一分钟之间
+var durationBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// histogram 简单的累积直方图
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(durationBuckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range durationBuckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Metrics 进程内的指标，以 Prometheus 文本格式输出
+type Metrics struct {
+	mu sync.Mutex
+
+	runs            float64
+	successes       float64
+	failures        map[string]float64
+	stepDurations   map[string]*histogram
+	executeDuration *histogram
+	chromeProcesses float64
+	userPoints      map[string]float64
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		failures:        make(map[string]float64),
+		stepDurations:   make(map[string]*histogram),
+		executeDuration: newHistogram(),
+		userPoints:      make(map[string]float64),
+	}
+}
+
+// RegisterSteps 预先输出各步骤的失败计数，便于告警规则使用 increase()
+func (m *Metrics) RegisterSteps(steps []Step) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, step := range steps {
+		if _, ok := m.failures[step.Name]; !ok {
+			m.failures[step.Name] = 0
+		}
+	}
+}
+
+// RunStarted 记录一次任务执行
+func (m *Metrics) RunStarted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs++
+}
+
+// RunSucceeded 记录一次成功的执行
+func (m *Metrics) RunSucceeded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.successes++
+}
+
+// RunFailed 记录一次失败的执行及失败的步骤
+func (m *Metrics) RunFailed(step string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[step]++
+}
+
+// ObserveStep 记录步骤耗时
+func (m *Metrics) ObserveStep(step string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.stepDurations[step]
+	if !ok {
+		h = newHistogram()
+		m.stepDurations[step] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// ObserveExecute 记录单次 Browser.Execute 的耗时
+func (m *Metrics) ObserveExecute(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executeDuration.observe(d.Seconds())
+}
+
+// SetChromeProcesses 记录当前 Chrome 进程数
+func (m *Metrics) SetChromeProcesses(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chromeProcesses = float64(count)
+}
+
+// SetUserPoints 记录用户积分
+func (m *Metrics) SetUserPoints(points UserPoints) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range points.Values {
+		m.userPoints[key] = value
+	}
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标
+func (m *Metrics) WriteTo(w io.Writer, lastSuccess time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sb strings.Builder
+
+	writeHeader(&sb, "daysign_runs_total", "counter", "任务执行次数")
+	sb.WriteString(fmt.Sprintf("daysign_runs_total %s\n", formatFloat(m.runs)))
+
+	writeHeader(&sb, "daysign_run_success_total", "counter", "任务成功次数")
+	sb.WriteString(fmt.Sprintf("daysign_run_success_total %s\n", formatFloat(m.successes)))
+
+	writeHeader(&sb, "daysign_run_failures_total", "counter", "任务失败次数，按失败的步骤区分")
+	for _, step := range sortedKeys(m.failures) {
+		sb.WriteString(fmt.Sprintf("daysign_run_failures_total{step=%s} %s\n", quoteLabel(step), formatFloat(m.failures[step])))
+	}
+
+	writeHeader(&sb, "daysign_step_duration_seconds", "histogram", "各步骤耗时")
+	for _, step := range sortedKeys(m.stepDurations) {
+		writeHistogram(&sb, "daysign_step_duration_seconds", "step="+quoteLabel(step)+",", m.stepDurations[step])
+	}
+
+	writeHeader(&sb, "daysign_browser_execute_duration_seconds", "histogram", "单次浏览器操作耗时")
+	writeHistogram(&sb, "daysign_browser_execute_duration_seconds", "", m.executeDuration)
+
+	writeHeader(&sb, "daysign_last_success_timestamp_seconds", "gauge", "上次任务成功的 Unix 时间戳")
+	var ts float64
+	if !lastSuccess.IsZero() {
+		ts = float64(lastSuccess.Unix())
+	}
+	sb.WriteString(fmt.Sprintf("daysign_last_success_timestamp_seconds %s\n", formatFloat(ts)))
+
+	writeHeader(&sb, "daysign_chrome_processes", "gauge", "当前 Chrome 相关进程数")
+	sb.WriteString(fmt.Sprintf("daysign_chrome_processes %s\n", formatFloat(m.chromeProcesses)))
+
+	writeHeader(&sb, "daysign_user_points", "gauge", "用户积分（威望/金币/貢獻值/邀請幣）")
+	for _, item := range sortedKeys(m.userPoints) {
+		sb.WriteString(fmt.Sprintf("daysign_user_points{item=%s} %s\n", quoteLabel(item), formatFloat(m.userPoints[item])))
+	}
+
+	io.WriteString(w, sb.String())
+}
+
+// metricsHandler 输出 /metrics
+func (a *App) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	a.metrics.WriteTo(w, a.state.Snapshot().LastSuccessTime)
+}
+
+func writeHeader(sb *strings.Builder, name, kind, help string) {
+	sb.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind))
+}
+
+func writeHistogram(sb *strings.Builder, name, labels string, h *histogram) {
+	for i, bound := range durationBuckets {
+		sb.WriteString(fmt.Sprintf("%s_bucket{%sle=%q} %d\n", name, labels, formatFloat(bound), h.counts[i]))
+	}
+	sb.WriteString(fmt.Sprintf("%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count))
+	suffix := ""
+	if labels != "" {
+		suffix = "{" + strings.TrimSuffix(labels, ",") + "}"
+	}
+	sb.WriteString(fmt.Sprintf("%s_sum%s %s\n", name, suffix, formatFloat(h.sum)))
+	sb.WriteString(fmt.Sprintf("%s_count%s %d\n", name, suffix, h.count))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// quoteLabel 按 Prometheus 文本格式转义标签值
+func quoteLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parsePointValue 从积分文本中解析出数字，例如 "1,234 点" -> 1234
+func parsePointValue(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' || (end == 0 && s[end] == '-')) {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
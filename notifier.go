@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MessageKind 通知消息的类型，便于各通知方式区分处理
+type MessageKind int
+
+const (
+	MessageInfo MessageKind = iota
+	MessageSuccess
+	MessageFailure
+)
+
+// String 返回消息类型的名称
+func (k MessageKind) String() string {
+	switch k {
+	case MessageSuccess:
+		return "success"
+	case MessageFailure:
+		return "failure"
+	default:
+		return "info"
+	}
+}
+
+// Message 一条通知消息
+type Message struct {
+	Kind  MessageKind
+	Title string
+	Text  string
+	// Image 附带的图片文件路径，如失败时的页面截图，目前只有 Telegram 会发送
+	Image string
+}
+
+// ErrNotificationDeferred 表示消息暂时发送失败，但已保存下来，稍后会自动补发
+var ErrNotificationDeferred = errors.New("通知发送失败，已保存待补发")
+
+// Notifier 通知方式的统一接口
+type Notifier interface {
+	// Name 返回通知方式名称，用于日志
+	Name() string
+	// Notify 发送一条消息
+	Notify(msg Message) error
+}
+
+// MultiNotifier 同时向多个通知方式发送消息，单个失败不影响其他通知方式
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+// Name 返回所有通知方式的名称
+func (m *MultiNotifier) Name() string {
+	names := make([]string, 0, len(m.notifiers))
+	for _, n := range m.notifiers {
+		names = append(names, n.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Notify 并发发送到所有通知方式，只有全部失败时才返回错误，
+// 避免部分失败触发重试后向已成功的通知方式重复发送
+func (m *MultiNotifier) Notify(msg Message) error {
+	if len(m.notifiers) == 0 {
+		log.Println("未配置任何通知方式，跳过发送通知")
+		return nil
+	}
+
+	errs := make([]error, len(m.notifiers))
+	var wg sync.WaitGroup
+	for i, n := range m.notifiers {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(msg); err != nil {
+				log.Printf("通过 %s 发送通知失败: %v", n.Name(), err)
+				errs[i] = fmt.Errorf("%s: %w", n.Name(), err)
+			}
+		}(i, n)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// buildNotifiers 根据配置构建通知方式。
+// notify.enabled 为空时启用所有已配置的通知方式，否则只启用列出的通知方式。
+// Telegram 通知复用传入的客户端，client 为 nil 时不启用
+func buildNotifiers(cfg *Config, client *TelegramClient) *MultiNotifier {
+	selected := make(map[string]bool)
+	for _, name := range cfg.Notify.Enabled {
+		selected[strings.ToLower(name)] = true
+	}
+	enabled := func(name string, configured bool) bool {
+		if len(selected) > 0 {
+			if selected[name] && !configured {
+				log.Printf("通知方式 %s 已启用但缺少配置，已忽略", name)
+			}
+			return selected[name] && configured
+		}
+		return configured
+	}
+
+	m := &MultiNotifier{}
+	n := cfg.Notify
+	telegramConfigured := cfg.Telegram.BotToken != "" &&
+		len(cfg.chatTargets)+len(cfg.successChatTargets)+len(cfg.failureChatTargets) > 0
+	if enabled("telegram", telegramConfigured && client != nil) {
+		m.notifiers = append(m.notifiers, &TelegramNotifier{
+			Client:         client,
+			ChatIDs:        cfg.chatTargets,
+			SuccessChatIDs: cfg.successChatTargets,
+			FailureChatIDs: cfg.failureChatTargets,
+		})
+	}
+	if enabled("dingtalk", n.DingTalk.Webhook != "") {
+		m.notifiers = append(m.notifiers, &DingTalkNotifier{Webhook: n.DingTalk.Webhook, Secret: n.DingTalk.Secret})
+	}
+	if enabled("wecom", n.WeCom.Webhook != "") {
+		m.notifiers = append(m.notifiers, &WeComNotifier{Webhook: n.WeCom.Webhook})
+	}
+	if enabled("email", n.Email.Host != "" && len(n.Email.To) > 0) {
+		from := n.Email.From
+		if from == "" {
+			from = n.Email.Username
+		}
+		m.notifiers = append(m.notifiers, &EmailNotifier{
+			Host:     n.Email.Host,
+			Port:     n.Email.Port,
+			Username: n.Email.Username,
+			Password: n.Email.Password,
+			From:     from,
+			To:       n.Email.To,
+		})
+	}
+	if enabled("bark", n.Bark.URL != "") {
+		m.notifiers = append(m.notifiers, &BarkNotifier{URL: n.Bark.URL})
+	}
+	if enabled("serverchan", n.ServerChan.SendKey != "") {
+		m.notifiers = append(m.notifiers, &ServerChanNotifier{SendKey: n.ServerChan.SendKey})
+	}
+	if enabled("webhook", n.Webhook.URL != "") {
+		m.notifiers = append(m.notifiers, &WebhookNotifier{URL: n.Webhook.URL})
+	}
+
+	if len(m.notifiers) > 0 {
+		log.Printf("已启用通知方式: %s", m.Name())
+	}
+	return m
+}
+
+// notifyHTTPClient 各 HTTP 类通知方式共用的客户端
+var notifyHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// postJSON 以 JSON 格式 POST 数据，并在 result 不为空时解析响应
+func postJSON(url string, payload any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := notifyHTTPClient.Post(url, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeNotifyResponse(resp, result)
+}
+
+// decodeNotifyResponse 检查 HTTP 状态码并解析响应
+func decodeNotifyResponse(resp *http.Response, result any) error {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifier 通过 SMTP 发送邮件通知
+type EmailNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Name 返回通知方式名称
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// emailDialTimeout 连接 SMTP 服务器的超时，emailTimeout 整个发送过程的超时，避免服务器无响应时阻塞通知
+const (
+	emailDialTimeout = 15 * time.Second
+	emailTimeout     = time.Minute
+)
+
+// Notify 发送纯文本邮件，465 端口使用 SMTPS，其余端口在服务器支持时使用 STARTTLS
+func (e *EmailNotifier) Notify(msg Message) error {
+	body := e.buildMessage(msg)
+	addr := net.JoinHostPort(e.Host, e.Port)
+	tlsConfig := &tls.Config{ServerName: e.Host}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	dialer := &net.Dialer{Timeout: emailDialTimeout}
+	var conn net.Conn
+	var err error
+	if e.Port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(emailTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if e.Port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("SMTP STARTTLS 失败: %w", err)
+			}
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage 构建 UTF-8 编码的邮件内容
+func (e *EmailNotifier) buildMessage(msg Message) []byte {
+	subject := msg.Title
+	if subject == "" {
+		subject = "daysign2048 通知"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("From: " + e.From + "\r\n")
+	sb.WriteString("To: " + strings.Join(e.To, ", ") + "\r\n")
+	sb.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Text))
+	for len(encoded) > 76 {
+		sb.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	sb.WriteString(encoded + "\r\n")
+	return []byte(sb.String())
+}
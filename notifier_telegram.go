@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ChatTarget 一个 Telegram 消息接收方，ThreadID 不为0时发送到群组的指定话题
+type ChatTarget struct {
+	ChatID   int64
+	ThreadID int
+}
+
+// String 返回 chat_id[:message_thread_id] 形式的字符串
+func (c ChatTarget) String() string {
+	if c.ThreadID != 0 {
+		return fmt.Sprintf("%d:%d", c.ChatID, c.ThreadID)
+	}
+	return strconv.FormatInt(c.ChatID, 10)
+}
+
+// parseChatTargets 解析逗号分隔的 chat_id 列表，每项可写作 chat_id 或 chat_id:message_thread_id
+func parseChatTargets(value string) ([]ChatTarget, error) {
+	var targets []ChatTarget
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var target ChatTarget
+		chatPart, threadPart, hasThread := strings.Cut(item, ":")
+		id, err := strconv.ParseInt(strings.TrimSpace(chatPart), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 chat_id %q: %w", item, err)
+		}
+		target.ChatID = id
+		if hasThread {
+			threadID, err := strconv.Atoi(strings.TrimSpace(threadPart))
+			if err != nil {
+				return nil, fmt.Errorf("无效的 message_thread_id %q: %w", item, err)
+			}
+			target.ThreadID = threadID
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// TelegramNotifier 通过 Telegram Bot 发送通知，按消息类型路由到不同的会话
+type TelegramNotifier struct {
+	Client *TelegramClient
+	// ChatIDs 默认接收方，未单独配置成功/失败接收方时使用
+	ChatIDs []ChatTarget
+	// SuccessChatIDs 接收成功消息的会话，例如个人聊天
+	SuccessChatIDs []ChatTarget
+	// FailureChatIDs 接收失败消息的会话，例如运维群
+	FailureChatIDs []ChatTarget
+}
+
+// Name 返回通知方式名称
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// targets 根据消息类型选择接收方
+func (t *TelegramNotifier) targets(kind MessageKind) []ChatTarget {
+	switch {
+	case kind == MessageSuccess && len(t.SuccessChatIDs) > 0:
+		return t.SuccessChatIDs
+	case kind == MessageFailure && len(t.FailureChatIDs) > 0:
+		return t.FailureChatIDs
+	default:
+		return t.ChatIDs
+	}
+}
+
+// Notify 通过共享客户端发送 Telegram 消息通知，某个会话发送失败不影响其他会话
+func (t *TelegramNotifier) Notify(msg Message) error {
+	targets := t.targets(msg.Kind)
+	if len(targets) == 0 {
+		return errors.New("未配置 Telegram 接收方")
+	}
+
+	var errs []error
+	for _, target := range targets {
+		if err := t.Client.Send(target, msg.Text); err != nil {
+			log.Printf("发送 Telegram 消息通知到 %s 失败: %v", target, err)
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+			continue
+		}
+		// 图片发送失败不影响通知结果，文字消息中已包含快照路径
+		if msg.Image != "" {
+			if err := t.Client.SendPhoto(target, msg.Image, msg.Title); err != nil {
+				log.Printf("发送图片到 %s 失败: %v", target, err)
+			}
+		}
+	}
+	// 只要有一个会话收到消息就视为成功，避免重试时重复发送
+	if len(errs) == len(targets) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// sendTelegramText 发送文本消息，当前版本的 tgbotapi 不支持话题，需直接构造请求参数
+func sendTelegramText(bot *tgbotapi.BotAPI, target ChatTarget, text string) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", target.ChatID)
+	params.AddNonZero("message_thread_id", target.ThreadID)
+	params.AddNonEmpty("text", text)
+	_, err := bot.MakeRequest("sendMessage", params)
+	return err
+}
+
+// sendTelegramPhoto 上传本地图片，与 sendTelegramText 一样直接构造请求参数以支持话题
+func sendTelegramPhoto(bot *tgbotapi.BotAPI, target ChatTarget, path, caption string) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", target.ChatID)
+	params.AddNonZero("message_thread_id", target.ThreadID)
+	params.AddNonEmpty("caption", caption)
+	files := []tgbotapi.RequestFile{{Name: "photo", Data: tgbotapi.FilePath(path)}}
+	_, err := bot.UploadFiles("sendPhoto", params, files)
+	return err
+}
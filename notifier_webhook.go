@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DingTalkNotifier 通过钉钉群机器人发送通知
+type DingTalkNotifier struct {
+	Webhook string
+	// Secret 机器人开启“加签”安全设置时使用
+	Secret string
+}
+
+// Name 返回通知方式名称
+func (d *DingTalkNotifier) Name() string {
+	return "dingtalk"
+}
+
+// Notify 发送钉钉文本消息
+func (d *DingTalkNotifier) Notify(msg Message) error {
+	webhook := d.Webhook
+	if d.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(d.Secret))
+		mac.Write([]byte(timestamp + "\n" + d.Secret))
+		sign := url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		webhook = fmt.Sprintf("%s&timestamp=%s&sign=%s", webhook, timestamp, sign)
+	}
+
+	payload := map[string]any{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg.Text},
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := postJSON(webhook, payload, &result); err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("钉钉返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// WeComNotifier 通过企业微信群机器人发送通知
+type WeComNotifier struct {
+	Webhook string
+}
+
+// Name 返回通知方式名称
+func (w *WeComNotifier) Name() string {
+	return "wecom"
+}
+
+// Notify 发送企业微信文本消息
+func (w *WeComNotifier) Notify(msg Message) error {
+	payload := map[string]any{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg.Text},
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := postJSON(w.Webhook, payload, &result); err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("企业微信返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// BarkNotifier 通过 Bark 推送到 iOS 设备
+type BarkNotifier struct {
+	// URL 形如 https://api.day.app/<device_key>
+	URL string
+}
+
+// Name 返回通知方式名称
+func (b *BarkNotifier) Name() string {
+	return "bark"
+}
+
+// Notify 发送 Bark 推送
+func (b *BarkNotifier) Notify(msg Message) error {
+	payload := map[string]string{
+		"title": msg.Title,
+		"body":  msg.Text,
+		"group": "daysign2048",
+	}
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := postJSON(strings.TrimRight(b.URL, "/"), payload, &result); err != nil {
+		return err
+	}
+	if result.Code != 200 {
+		return fmt.Errorf("Bark 返回错误 %d: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// ServerChanNotifier 通过 Server酱 推送到微信
+type ServerChanNotifier struct {
+	SendKey string
+}
+
+// Name 返回通知方式名称
+func (s *ServerChanNotifier) Name() string {
+	return "serverchan"
+}
+
+// Notify 发送 Server酱 消息
+func (s *ServerChanNotifier) Notify(msg Message) error {
+	endpoint := fmt.Sprintf("https://sctapi.ftqq.com/%s.send", s.SendKey)
+	form := url.Values{}
+	form.Set("title", msg.Title)
+	// Server酱 的正文为 Markdown，换行需要空行才能显示
+	form.Set("desp", strings.ReplaceAll(msg.Text, "\n", "\n\n"))
+
+	resp, err := notifyHTTPClient.PostForm(endpoint, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := decodeNotifyResponse(resp, &result); err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("Server酱返回错误 %d: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// WebhookNotifier 向任意地址 POST 通用 JSON 消息
+type WebhookNotifier struct {
+	URL string
+}
+
+// Name 返回通知方式名称
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify 发送通用 JSON 消息
+func (w *WebhookNotifier) Notify(msg Message) error {
+	payload := map[string]string{
+		"kind":  msg.Kind.String(),
+		"title": msg.Title,
+		"text":  msg.Text,
+		"time":  time.Now().Format(time.RFC3339),
+	}
+	return postJSON(w.URL, payload, nil)
+}
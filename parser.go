@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// parseFirstPost 从回帖页 HTML 中提取要回复的帖子。
+// 优先取广告注释之后第一个有标题链接的帖子；页面没有该注释时，跳过置顶帖取第一个普通帖子
+func parseFirstPost(htmlContent string, sel ThreadSelectors) (title string, href string, err error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", "", err
+	}
+
+	// 定位帖子列表，默认为 table#ajaxtable 下的第二个 tbody
+	tbody := doc.Find(sel.Body).First()
+	if tbody.Length() == 0 {
+		return "", "", fmt.Errorf("未找到帖子列表 %s", sel.Body)
+	}
+
+	// 遍历帖子列表的所有子节点，查找包含广告标记的注释节点
+	var rows *goquery.Selection
+	if sel.AdComment != "" {
+		tbody.Contents().EachWithBreak(func(i int, s *goquery.Selection) bool {
+			node := s.Get(0)
+			if node.Type == html.CommentNode && strings.Contains(node.Data, sel.AdComment) {
+				// 注释之后的所有帖子行
+				rows = s.NextAllFiltered(sel.Row)
+				return false
+			}
+			return true
+		})
+	}
+	if rows == nil {
+		rows = tbody.ChildrenFiltered(sel.Row).FilterFunction(func(i int, s *goquery.Selection) bool {
+			return !isPinnedThread(s)
+		})
+	}
+
+	// 跳过没有标题链接的空行
+	rows.EachWithBreak(func(i int, s *goquery.Selection) bool {
+		a := s.Find(sel.Link).First()
+		if a.Length() == 0 {
+			return true
+		}
+		link, ok := a.Attr("href")
+		if !ok || strings.TrimSpace(link) == "" {
+			return true
+		}
+		title, href = strings.TrimSpace(a.Text()), strings.TrimSpace(link)
+		return false
+	})
+	if href == "" {
+		return "", "", errors.New("未找到可回复的帖子")
+	}
+	return title, href, nil
+}
+
+// isPinnedThread 判断帖子行是否为置顶帖
+func isPinnedThread(row *goquery.Selection) bool {
+	pinned := false
+	row.Find("img").EachWithBreak(func(i int, img *goquery.Selection) bool {
+		src, _ := img.Attr("src")
+		alt, _ := img.Attr("alt")
+		title, _ := img.Attr("title")
+		text := alt + title
+		if strings.Contains(src, "headtopic") || strings.Contains(text, "置顶") || strings.Contains(text, "置頂") {
+			pinned = true
+			return false
+		}
+		return true
+	})
+	return pinned
+}
+
+// parseUserInfo 从个人资料页 HTML 的积分表格中提取积分，
+// 默认每行的 td 为积分名称、th 为数值，简体和繁体名称都会被识别
+func parseUserInfo(htmlContent string, sel ProfileSelectors) (UserPoints, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return UserPoints{}, err
+	}
+
+	userInfo := make(map[string]string)
+	doc.Find(sel.Rows).Each(func(i int, s *goquery.Selection) {
+		key := strings.TrimSpace(s.Find(sel.Key).First().Text())
+		value := strings.TrimSpace(s.Find(sel.Value).First().Text())
+		if label, ok := pointLabel(key); ok && value != "" {
+			userInfo[label] = value
+		}
+	})
+
+	// 没有找到任何信息
+	if len(userInfo) == 0 {
+		return UserPoints{}, nil
+	}
+	return newUserPoints(userInfo), nil
+}
+
+// ReplyResult 回帖的实际结果
+type ReplyResult struct {
+	Content string
+	// Posted 是否在帖子中找到了本账号的这条回复，为 false 且没有 Message 时表示无法确认结果
+	Posted bool
+	// Message 论坛的提示文本，如发帖过快、需要审核
+	Message string
+}
+
+// countOwnReplies 统计页面中作者为 username、正文包含 content 的楼层数。
+// 作者元素应只包含用户名，去掉首尾空白后需与 username 完全一致
+func countOwnReplies(doc *goquery.Document, sel ReplySelectors, username, content string) int {
+	want := collapseSpace(content)
+	count := 0
+	doc.Find(sel.Post).Each(func(i int, s *goquery.Selection) {
+		author := strings.TrimSpace(s.Find(sel.Author).First().Text())
+		if author != "" && author == strings.TrimSpace(username) &&
+			strings.Contains(collapseSpace(s.Find(sel.Content).Text()), want) {
+			count++
+		}
+	})
+	return count
+}
+
+// parseOwnReplies 统计提交回帖前页面中已有的相同回复，用于之后区分新旧回复
+func parseOwnReplies(htmlContent string, sel ReplySelectors, username, content string) (int, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return 0, err
+	}
+	return countOwnReplies(doc, sel, username, content), nil
+}
+
+// parseReplyResult 检查提交回帖后的页面：作者为 username、正文包含 content 的楼层比提交前的 before 多时
+// 认为回帖成功，避免把之前相同内容的回复当成本次的回复；没有新的回复且页面中没有楼层时读取论坛的提示文本。
+// 帖子页的正文也可能带有提示文本的样式，因此有楼层时不读取提示
+func parseReplyResult(htmlContent string, sel ReplySelectors, username, content string, before int) (ReplyResult, error) {
+	result := ReplyResult{Content: content}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return result, err
+	}
+
+	result.Posted = countOwnReplies(doc, sel, username, content) > before
+	if !result.Posted && sel.Notice != "" && doc.Find(sel.Post).Length() == 0 {
+		result.Message = collapseSpace(doc.Find(sel.Notice).First().Text())
+	}
+	return result, nil
+}
+
+// collapseSpace 去掉首尾空白，并把连续的空白合并为一个空格
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("读取测试页面 %s 失败: %v", name, err)
+	}
+	return string(data)
+}
+
+// threadPage 拼出只包含帖子列表的回帖页
+func threadPage(rows string) string {
+	return `<html><body><table id="ajaxtable">
+<tbody><tr class="tr2"><td>文章</td></tr></tbody>
+<tbody>` + rows + `</tbody>
+</table></body></html>`
+}
+
+const (
+	pinnedRow  = `<tr class="tr3 t_one"><td><img src="images/wind/thread/headtopic_3.gif" alt="置顶帖标志"></td><td><a href="read.php?tid=1" class="subject">置顶帖</a></td></tr>`
+	emptyRow   = `<tr class="tr3 t_one"><td></td><td></td></tr>`
+	adRow      = `<tr class="tr4"><td colspan="5"><a href="http://ad.example.com">广告</a></td></tr>`
+	normalRow  = `<tr class="tr3 t_one"><td><img src="images/wind/thread/topicnew.gif"></td><td><a href="read.php?tid=2" class="subject"> 普通帖 </a></td></tr>`
+	normalRow2 = `<tr class="tr3 t_one"><td></td><td><a href="read.php?tid=3" class="subject">第二个普通帖</a></td></tr>`
+)
+
+func TestParseFirstPostFixture(t *testing.T) {
+	title, href, err := parseFirstPost(readFixture(t, "thread_fid57.html"), defaultSiteProfile().Thread)
+	if err != nil {
+		t.Fatalf("parseFirstPost 返回错误: %v", err)
+	}
+	if title != "[MP4/2.1G] 新片速递 第一集" || href != "read.php?tid=2001" {
+		t.Errorf("parseFirstPost = %q, %q", title, href)
+	}
+}
+
+func TestParseFirstPost(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		wantTitle string
+		wantHref  string
+		wantErr   bool
+	}{
+		{
+			name:      "广告注释后的第一个帖子",
+			html:      threadPage(pinnedRow + "<!-- 广告连接 -->" + normalRow + normalRow2),
+			wantTitle: "普通帖",
+			wantHref:  "read.php?tid=2",
+		},
+		{
+			name:      "广告注释和帖子之间有广告行",
+			html:      threadPage(pinnedRow + "<!--广告连接-->" + adRow + normalRow),
+			wantTitle: "普通帖",
+			wantHref:  "read.php?tid=2",
+		},
+		{
+			name:      "跳过广告注释后的空行",
+			html:      threadPage("<!-- 广告连接 -->" + emptyRow + normalRow2),
+			wantTitle: "第二个普通帖",
+			wantHref:  "read.php?tid=3",
+		},
+		{
+			name:      "没有广告注释时跳过置顶帖",
+			html:      threadPage(pinnedRow + pinnedRow + normalRow),
+			wantTitle: "普通帖",
+			wantHref:  "read.php?tid=2",
+		},
+		{
+			name:      "置顶帖使用繁体标志",
+			html:      threadPage(`<tr class="tr3 t_one"><td><img src="a.gif" title="置頂帖標誌"></td><td><a href="read.php?tid=1" class="subject">置頂</a></td></tr>` + normalRow2),
+			wantTitle: "第二个普通帖",
+			wantHref:  "read.php?tid=3",
+		},
+		{
+			name:    "广告注释后只有空行",
+			html:    threadPage(pinnedRow + "<!-- 广告连接 -->" + emptyRow + emptyRow),
+			wantErr: true,
+		},
+		{
+			name:    "只有置顶帖",
+			html:    threadPage(pinnedRow),
+			wantErr: true,
+		},
+		{
+			name:    "缺少第二个 tbody",
+			html:    `<table id="ajaxtable"><tbody>` + normalRow + `</tbody></table>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, href, err := parseFirstPost(tt.html, defaultSiteProfile().Thread)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望返回错误，实际得到 %q, %q", title, href)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFirstPost 返回错误: %v", err)
+			}
+			if title != tt.wantTitle || href != tt.wantHref {
+				t.Errorf("parseFirstPost = %q, %q，期望 %q, %q", title, href, tt.wantTitle, tt.wantHref)
+			}
+		})
+	}
+}
+
+func TestParseUserInfoFixture(t *testing.T) {
+	points, err := parseUserInfo(readFixture(t, "u_show.html"), defaultSiteProfile().Profile)
+	if err != nil {
+		t.Fatalf("parseUserInfo 返回错误: %v", err)
+	}
+	wantRaw := map[string]string{"威望": "15", "金币": "1,234", "貢獻值": "87", "邀請幣": "2"}
+	wantValues := map[string]float64{"prestige": 15, "gold": 1234, "contribution": 87, "invitation": 2}
+	if !reflect.DeepEqual(points.Raw, wantRaw) {
+		t.Errorf("Raw = %v，期望 %v", points.Raw, wantRaw)
+	}
+	if !reflect.DeepEqual(points.Values, wantValues) {
+		t.Errorf("Values = %v，期望 %v", points.Values, wantValues)
+	}
+}
+
+func TestParseUserInfo(t *testing.T) {
+	table := func(rows string) string {
+		return `<div class="pwB_uConside_a"><table class="pwB_uTable_a">` + rows + `</table></div>`
+	}
+
+	tests := []struct {
+		name string
+		html string
+		want map[string]float64
+	}{
+		{
+			name: "简体名称",
+			html: table(`<tr><td>威望</td><th>1</th></tr><tr><td>金币</td><th>20</th></tr><tr><td>贡献值</td><th>3</th></tr><tr><td>邀请币</td><th>4</th></tr>`),
+			want: map[string]float64{"prestige": 1, "gold": 20, "contribution": 3, "invitation": 4},
+		},
+		{
+			name: "繁体名称",
+			html: table(`<tr><td>威望</td><th>1</th></tr><tr><td>金幣</td><th>20</th></tr><tr><td>貢獻值</td><th>3</th></tr><tr><td>邀請幣</td><th>4</th></tr>`),
+			want: map[string]float64{"prestige": 1, "gold": 20, "contribution": 3, "invitation": 4},
+		},
+		{
+			name: "名称和数值两侧有空白",
+			html: table(`<tr><td> 金币 </td><th>
+				1,000 枚
+			</th></tr>`),
+			want: map[string]float64{"gold": 1000},
+		},
+		{
+			name: "跳过空行和无关的行",
+			html: table(`<tr></tr><tr><td></td><th></th></tr><tr><td>威望</td><th></th></tr><tr><td>发帖</td><th>9</th></tr><tr><td>金币</td><th>5</th></tr>`),
+			want: map[string]float64{"gold": 5},
+		},
+		{
+			name: "没有积分表格",
+			html: `<div class="pwB_uConside_a"><p>该用户设置了隐私保护</p></div>`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := parseUserInfo(tt.html, defaultSiteProfile().Profile)
+			if err != nil {
+				t.Fatalf("parseUserInfo 返回错误: %v", err)
+			}
+			if len(tt.want) == 0 {
+				if len(points.Raw) != 0 {
+					t.Errorf("期望没有积分，实际得到 %v", points.Raw)
+				}
+				return
+			}
+			if !reflect.DeepEqual(points.Values, tt.want) {
+				t.Errorf("Values = %v，期望 %v", points.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplyResult(t *testing.T) {
+	floor := func(author, content string) string {
+		return `<div class="t5"><table><tr><th class="r_two"><div class="readName b"><a href="u.php?uid=1">` + author +
+			`</a></div><div>级别: 新手上路 金币: 100</div></th><th><div class="tpc_content"><div class="f14">` + content + `</div></div></th></tr></table></div>`
+	}
+	page := func(body string) string {
+		return `<html><body><div id="main">` + body + `</div></body></html>`
+	}
+
+	tests := []struct {
+		name        string
+		html        string
+		before      int
+		wantPosted  bool
+		wantMessage string
+	}{
+		{
+			name:       "找到本账号的回复",
+			html:       page(floor("someone", "感谢分享！！") + floor("testuser", "感谢分享！！")),
+			wantPosted: true,
+		},
+		{
+			name:       "正文中的空白不同",
+			html:       page(floor(" testuser ", "\n  感谢分享！！ \n")),
+			wantPosted: true,
+		},
+		{
+			name: "其他用户的相同回复不算",
+			html: page(floor("someone", "感谢分享！！")),
+		},
+		{
+			name: "用户名包含本账号的用户不算",
+			html: page(floor("testuser2", "感谢分享！！") + floor("my_testuser", "感谢分享！！")),
+		},
+		{
+			name:   "提交前已有的相同回复不算",
+			html:   page(floor("testuser", "感谢分享！！")),
+			before: 1,
+		},
+		{
+			name:       "比提交前多出一条相同回复",
+			html:       page(floor("testuser", "感谢分享！！") + floor("someone", "顶") + floor("testuser", "感谢分享！！")),
+			before:     1,
+			wantPosted: true,
+		},
+		{
+			name: "帖子页正文中的提示样式不算论坛提示",
+			html: page(floor("someone", "顶一下")),
+		},
+		{
+			name:        "论坛提示页",
+			html:        page(`<div class="f14">发帖间隔不能少于 30 秒</div>`),
+			wantMessage: "发帖间隔不能少于 30 秒",
+		},
+		{
+			name:        "需要审核时提示在表格中",
+			html:        page(`<table><tr><td class="f_one">您的帖子需要管理员审核后才能显示</td></tr></table>`),
+			wantMessage: "您的帖子需要管理员审核后才能显示",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseReplyResult(tt.html, defaultSiteProfile().Reply, "testuser", "感谢分享！！", tt.before)
+			if err != nil {
+				t.Fatalf("parseReplyResult 返回错误: %v", err)
+			}
+			if result.Posted != tt.wantPosted || result.Message != tt.wantMessage {
+				t.Errorf("parseReplyResult = %+v，期望 Posted=%v Message=%q", result, tt.wantPosted, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestParseReplyResultFixture(t *testing.T) {
+	page := readFixture(t, "read_tid2001.html")
+	sel := defaultSiteProfile().Reply
+
+	tests := []struct {
+		name       string
+		username   string
+		content    string
+		before     int
+		wantPosted bool
+	}{
+		{name: "找到本账号的回复", username: "testuser", content: "感谢分享！！", wantPosted: true},
+		{name: "提交前已有的回复不算", username: "testuser", content: "感谢分享！！", before: 1},
+		{name: "用户名为前缀的其他用户不算", username: "testuse", content: "感谢分享！！"},
+		{name: "正文不同", username: "testuser", content: "楼主辛苦了"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseReplyResult(page, sel, tt.username, tt.content, tt.before)
+			if err != nil {
+				t.Fatalf("parseReplyResult 返回错误: %v", err)
+			}
+			// 帖子页中正文的 f14 样式不能被当成论坛提示
+			if result.Posted != tt.wantPosted || result.Message != "" {
+				t.Errorf("parseReplyResult = %+v，期望 Posted=%v 且没有提示", result, tt.wantPosted)
+			}
+		})
+	}
+}
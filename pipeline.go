@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// StepOutput 步骤产出的数据，会随步骤结果一起持久化，供后续步骤和重试使用
+type StepOutput map[string]string
+
+// RetryPolicy 单个步骤在一次执行内的重试策略
+type RetryPolicy struct {
+	// Attempts 最多尝试次数，小于1时按1处理
+	Attempts int
+	// Delay 两次尝试之间的等待时间
+	Delay time.Duration
+}
+
+// Step 流水线中的一个命名步骤
+type Step struct {
+	Name  string
+	Title string
+	// Session 表示该步骤只负责建立浏览器会话（如登录），
+	// 每次执行都要重新运行，但仅当后续还有需要浏览器的步骤未完成时才运行
+	Session bool
+	// NeedsBrowser 表示该步骤依赖浏览器会话
+	NeedsBrowser bool
+	Retry        RetryPolicy
+	Run          func(tc *TaskContext) (StepOutput, error)
+}
+
+// Pipeline 有序的步骤列表，重试时从第一个未完成的步骤继续
+type Pipeline struct {
+	Steps []Step
+}
+
+// StepError 记录失败的步骤
+type StepError struct {
+	Step  Step
+	Cause error
+	// Snapshot 失败时保存的页面快照，没有浏览器或保存失败时为 nil
+	Snapshot *DebugSnapshot
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s失败: %v", e.Step.Title, e.Cause)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Cause
+}
+
+// TaskContext 在一次执行的各步骤之间共享论坛驱动和已完成步骤的输出
+type TaskContext struct {
+	app    *App
+	driver SiteDriver
+	state  TaskState
+}
+
+// Driver 按需创建论坛驱动，只有需要浏览器的步骤才会启动 Chrome
+func (tc *TaskContext) Driver() (SiteDriver, error) {
+	if tc.driver != nil {
+		return tc.driver, nil
+	}
+	driver, err := tc.app.newDriver()
+	if err != nil {
+		return nil, fmt.Errorf("创建浏览器失败: %w", err)
+	}
+	tc.driver = driver
+	return driver, nil
+}
+
+// Output 读取之前步骤（包括今天之前的执行）产出的数据
+func (tc *TaskContext) Output(step, key string) string {
+	return tc.state.Steps[step].Output[key]
+}
+
+// Close 关闭本次执行中创建的浏览器
+func (tc *TaskContext) Close() {
+	if tc.driver != nil {
+		log.Println("关闭浏览器实例...")
+		tc.driver.Close()
+		tc.driver = nil
+	}
+}
+
+// captureSnapshot 保存失败步骤所在页面的截图、HTML 和地址，步骤未使用浏览器时返回 nil
+func (tc *TaskContext) captureSnapshot(step Step) *DebugSnapshot {
+	if !step.NeedsBrowser || tc.driver == nil {
+		return nil
+	}
+	snap, err := tc.driver.Snapshot()
+	if err != nil {
+		log.Printf("获取页面快照失败: %v", err)
+		// 截图失败时仍尽量保存已取得的地址和 HTML
+		if snap.URL == "" && snap.HTML == "" {
+			return nil
+		}
+	}
+	saved, err := saveDebugSnapshot(step.Name, snap)
+	if err != nil {
+		log.Printf("保存页面快照失败: %v", err)
+		return nil
+	}
+	log.Printf("步骤 %s 失败时的页面快照已保存到 %s", step.Name, saved.Dir)
+	cleanupOldSnapshots(7)
+	return saved
+}
+
+// Done 判断今天所有非会话步骤是否都已成功
+func (p *Pipeline) Done(state TaskState) bool {
+	for _, step := range p.Steps {
+		if !step.Session && !state.Steps[step.Name].Success {
+			return false
+		}
+	}
+	return true
+}
+
+// browserNeeded 判断是否还有依赖浏览器的步骤尚未完成
+func (p *Pipeline) browserNeeded(state TaskState) bool {
+	for _, step := range p.Steps {
+		if !step.Session && step.NeedsBrowser && !state.Steps[step.Name].Success {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 依次执行未完成的步骤，遇到失败立即返回 *StepError
+func (p *Pipeline) Run(tc *TaskContext) error {
+	tc.state = tc.app.state.Snapshot()
+	needBrowser := p.browserNeeded(tc.state)
+
+	for _, step := range p.Steps {
+		if step.Session && !needBrowser {
+			continue
+		}
+		if !step.Session && tc.state.Steps[step.Name].Success {
+			log.Printf("步骤 %s 今天已完成，跳过", step.Name)
+			continue
+		}
+
+		output, err := p.runStep(step, tc)
+		tc.app.state.RecordStep(step.Name, output, err)
+		if err != nil {
+			return &StepError{Step: step, Cause: err, Snapshot: tc.captureSnapshot(step)}
+		}
+
+		// 刷新快照，让后续步骤读到本步骤的输出
+		tc.state = tc.app.state.Snapshot()
+	}
+	return nil
+}
+
+// runStep 按步骤的重试策略执行单个步骤
+func (p *Pipeline) runStep(step Step, tc *TaskContext) (StepOutput, error) {
+	attempts := step.Retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		log.Printf("执行步骤 %s (%d/%d)", step.Name, attempt, attempts)
+		start := time.Now()
+		output, err := step.Run(tc)
+		tc.app.metrics.ObserveStep(step.Name, time.Since(start))
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+		log.Printf("步骤 %s 第 %d 次执行失败: %v", step.Name, attempt, err)
+		// 登录失效或要求先回帖时原地重试没有意义，交给调度器重新登录或重新回帖
+		if errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrReplyRequired) {
+			break
+		}
+		if attempt < attempts && step.Retry.Delay > 0 {
+			time.Sleep(step.Retry.Delay)
+		}
+	}
+	return nil, lastErr
+}
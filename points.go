@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPointsHistoryFile 积分历史记录默认路径
+const DefaultPointsHistoryFile = "./state/points_history.csv"
+
+// pointItem 一个积分项：论坛上显示的名称和记录/指标中使用的键
+type pointItem struct {
+	Label string
+	Key   string
+	// Aliases 论坛切换简繁体时同一积分项的其他写法
+	Aliases []string
+}
+
+// pointItems 需要记录的积分项，按显示顺序排列
+var pointItems = []pointItem{
+	{Label: "威望", Key: "prestige"},
+	{Label: "金币", Key: "gold", Aliases: []string{"金幣"}},
+	{Label: "貢獻值", Key: "contribution", Aliases: []string{"贡献值"}},
+	{Label: "邀請幣", Key: "invitation", Aliases: []string{"邀请币"}},
+}
+
+// pointLabel 将论坛上显示的名称（简体或繁体）转换为积分项的标准名称
+func pointLabel(text string) (string, bool) {
+	for _, item := range pointItems {
+		if text == item.Label || slices.Contains(item.Aliases, text) {
+			return item.Label, true
+		}
+	}
+	return "", false
+}
+
+// UserPoints 从个人资料页解析出的积分
+type UserPoints struct {
+	// Raw 论坛上显示的原始文本，键为积分项名称
+	Raw map[string]string
+	// Values 解析成数字的积分，键为 pointItem.Key
+	Values map[string]float64
+}
+
+// newUserPoints 将原始文本解析为数字，无法解析的项只保留原始文本
+func newUserPoints(raw map[string]string) UserPoints {
+	p := UserPoints{Raw: raw, Values: make(map[string]float64)}
+	for _, item := range pointItems {
+		if v, ok := parsePointValue(raw[item.Label]); ok {
+			p.Values[item.Key] = v
+		}
+	}
+	return p
+}
+
+// String 格式化为通知文本
+func (p UserPoints) String() string {
+	if len(p.Raw) == 0 {
+		return "无法获取用户积分信息"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 用户积分信息 📊\n")
+	for _, item := range pointItems {
+		if value, ok := p.Raw[item.Label]; ok {
+			sb.WriteString(fmt.Sprintf("📌 %s: %s\n", item.Label, value))
+		}
+	}
+	return sb.String()
+}
+
+// pointsRecord 积分历史中的一行
+type pointsRecord struct {
+	Time   time.Time
+	Values map[string]float64
+}
+
+// PointsHistory 以 CSV 文件保存的积分历史
+type PointsHistory struct {
+	path string
+}
+
+// NewPointsHistory 创建积分历史
+func NewPointsHistory(path string) *PointsHistory {
+	return &PointsHistory{path: path}
+}
+
+// Append 追加一条记录，文件不存在时先写入表头
+func (h *PointsHistory) Append(t time.Time, points UserPoints) error {
+	if len(points.Values) == 0 {
+		return errors.New("没有可记录的积分")
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(h.path)
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if errors.Is(statErr, os.ErrNotExist) {
+		header := []string{"time"}
+		for _, item := range pointItems {
+			header = append(header, item.Key)
+		}
+		w.Write(header)
+	}
+
+	row := []string{t.Format(time.RFC3339)}
+	for _, item := range pointItems {
+		if v, ok := points.Values[item.Key]; ok {
+			row = append(row, formatFloat(v))
+		} else {
+			row = append(row, "")
+		}
+	}
+	w.Write(row)
+	w.Flush()
+	return w.Error()
+}
+
+// Load 读取全部历史记录
+func (h *PointsHistory) Load() ([]pointsRecord, error) {
+	file, err := os.Open(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []pointsRecord
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.ParseInLocation(time.RFC3339, row[0], time.Local)
+		if err != nil {
+			log.Printf("跳过无法解析的积分记录 %v: %v", row, err)
+			continue
+		}
+		record := pointsRecord{Time: t, Values: make(map[string]float64)}
+		for i := 1; i < len(row) && i < len(header); i++ {
+			if v, err := strconv.ParseFloat(row[i], 64); err == nil {
+				record.Values[header[i]] = v
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// BuildReport 生成 [start, end) 期间的积分报告：增量、日均增长、签到天数、漏签天数和连续签到天数。
+// 有积分记录的日期视为当天完成了签到
+func (h *PointsHistory) BuildReport(title string, start, end time.Time) (string, error) {
+	records, err := h.Load()
+	if err != nil {
+		return "", err
+	}
+
+	var baseline, latest *pointsRecord
+	signedDays := make(map[string]bool)
+	for i := range records {
+		record := &records[i]
+		signedDays[record.Time.Format("2006-01-02")] = true
+		switch {
+		case record.Time.Before(start):
+			baseline = record
+		case record.Time.Before(end):
+			if baseline == nil {
+				baseline = record
+			}
+			latest = record
+		}
+	}
+
+	days := int(end.Sub(start).Hours()/24 + 0.5)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📈 %s (%s ~ %s)\n", title,
+		start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02")))
+
+	if latest == nil {
+		sb.WriteString("期间没有积分记录\n")
+	} else {
+		elapsedDays := latest.Time.Sub(baseline.Time).Hours() / 24
+		for _, item := range pointItems {
+			current, ok := latest.Values[item.Key]
+			if !ok {
+				continue
+			}
+			delta := current - baseline.Values[item.Key]
+			line := fmt.Sprintf("📌 %s: %s (%+g", item.Label, formatFloat(current), delta)
+			if elapsedDays >= 1 {
+				line += fmt.Sprintf("，日均 %+.2f", delta/elapsedDays)
+			}
+			sb.WriteString(line + ")\n")
+		}
+	}
+
+	signed := 0
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		if signedDays[d.Format("2006-01-02")] {
+			signed++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("✅ 签到天数: %d/%d，漏签 %d 天\n", signed, days, days-signed))
+	sb.WriteString(fmt.Sprintf("🔥 连续签到: %d 天", signingStreak(signedDays, time.Now())))
+	return sb.String(), nil
+}
+
+// signingStreak 计算截至今天的连续签到天数，今天尚未签到时从昨天开始计算
+func signingStreak(signedDays map[string]bool, now time.Time) int {
+	day := now
+	if !signedDays[day.Format("2006-01-02")] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for signedDays[day.Format("2006-01-02")] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// sendPointsReport 生成并发送周报或月报
+func (a *App) sendPointsReport(monthly bool) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	title := "积分周报"
+	start, end := midnight.AddDate(0, 0, -7), midnight
+	if monthly {
+		title = "积分月报"
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(0, -1, 0)
+	}
+
+	report, err := a.history.BuildReport(title, start, end)
+	if err != nil {
+		log.Printf("生成%s失败: %v", title, err)
+		return
+	}
+	if err := a.notifier.Notify(Message{Kind: MessageInfo, Title: "hjd2048 " + title, Text: report}); err != nil {
+		log.Printf("发送%s失败: %v", title, err)
+	}
+}
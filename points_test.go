@@ -0,0 +1,113 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigningStreak(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	days := func(dates ...string) map[string]bool {
+		m := make(map[string]bool)
+		for _, d := range dates {
+			m[d] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		signed map[string]bool
+		want   int
+	}{
+		{name: "没有记录", signed: days(), want: 0},
+		{name: "今天已签到", signed: days("2024-05-10", "2024-05-09", "2024-05-08"), want: 3},
+		{name: "今天尚未签到从昨天算起", signed: days("2024-05-09", "2024-05-08"), want: 2},
+		{name: "中间漏签", signed: days("2024-05-10", "2024-05-08", "2024-05-07"), want: 1},
+		{name: "昨天也没有签到", signed: days("2024-05-08", "2024-05-07"), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signingStreak(tt.signed, now); got != tt.want {
+				t.Errorf("signingStreak = %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPointsHistoryAppendLoad(t *testing.T) {
+	history := NewPointsHistory(filepath.Join(t.TempDir(), "points_history.csv"))
+	if records, err := history.Load(); err != nil || records != nil {
+		t.Fatalf("文件不存在时 Load = %v, %v", records, err)
+	}
+	if err := history.Append(time.Now(), UserPoints{}); err == nil {
+		t.Error("没有积分时 Append 应返回错误")
+	}
+
+	at := time.Date(2024, 5, 1, 0, 20, 0, 0, time.Local)
+	points := newUserPoints(map[string]string{"威望": "15", "金币": "100"})
+	for i := 0; i < 2; i++ {
+		if err := history.Append(at.AddDate(0, 0, i), points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := history.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !records[1].Time.Equal(at.AddDate(0, 0, 1)) {
+		t.Fatalf("Load = %+v，期望两条记录", records)
+	}
+	// 没有解析出的积分项不应出现在记录中
+	if v := records[0].Values; v["gold"] != 100 || v["prestige"] != 15 || len(v) != 2 {
+		t.Errorf("积分 = %v", v)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	history := NewPointsHistory(filepath.Join(t.TempDir(), "points_history.csv"))
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 7)
+
+	for _, r := range []struct {
+		day  int
+		gold string
+	}{
+		{day: -1, gold: "100"}, // 期间开始前的记录作为基准
+		{day: 0, gold: "105"},
+		{day: 1, gold: "110"},
+		{day: 3, gold: "120"},
+		{day: 7, gold: "200"}, // 期间结束后的记录不计入
+	} {
+		points := newUserPoints(map[string]string{"金币": r.gold})
+		if err := history.Append(start.AddDate(0, 0, r.day).Add(20*time.Minute), points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := history.BuildReport("积分周报", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"积分周报 (2024-05-06 ~ 2024-05-12)",
+		"金币: 120 (+20，日均 +5.00)",
+		"签到天数: 3/7，漏签 4 天",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("报告缺少 %q:\n%s", want, report)
+		}
+	}
+
+	empty, err := history.BuildReport("积分月报", start.AddDate(0, -2, 0), start.AddDate(0, -1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(empty, "期间没有积分记录") || !strings.Contains(empty, "漏签") {
+		t.Errorf("没有记录的报告:\n%s", empty)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SiteProfile 论坛页面用到的选择器和关键字。
+// 镜像域名或模板变化时只需修改配置或选择器文件，不需要改代码
+type SiteProfile struct {
+	Header  HeaderSelectors  `yaml:"header"`
+	Login   LoginSelectors   `yaml:"login"`
+	Thread  ThreadSelectors  `yaml:"thread"`
+	Reply   ReplySelectors   `yaml:"reply"`
+	CheckIn CheckInSelectors `yaml:"checkin"`
+	Profile ProfileSelectors `yaml:"profile"`
+}
+
+// HeaderSelectors 页头，用于判断是否已登录
+type HeaderSelectors struct {
+	Selector string `yaml:"selector"`
+	// LoggedIn 已登录时页头中出现的文字
+	LoggedIn string `yaml:"logged_in"`
+	// LoggedOut 未登录时页头中出现的文字
+	LoggedOut string `yaml:"logged_out"`
+}
+
+// LoginSelectors 登录表单，字段支持 CSS 选择器或 XPath
+type LoginSelectors struct {
+	Form     string `yaml:"form"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Question string `yaml:"question"`
+	Answer   string `yaml:"answer"`
+	Submit   string `yaml:"submit"`
+	// 以下为表单字段的 name，HTTP 驱动直接提交表单时使用
+	UsernameField string `yaml:"username_field"`
+	PasswordField string `yaml:"password_field"`
+	QuestionField string `yaml:"question_field"`
+	AnswerField   string `yaml:"answer_field"`
+}
+
+// ThreadSelectors 回帖版块的帖子列表
+type ThreadSelectors struct {
+	// List 等待其可见后再读取页面
+	List string `yaml:"list"`
+	// Body 包含帖子行的元素
+	Body string `yaml:"body"`
+	Row  string `yaml:"row"`
+	Link string `yaml:"link"`
+	// AdComment 该注释之后才是普通帖子，页面没有该注释时跳过置顶帖
+	AdComment string `yaml:"ad_comment"`
+}
+
+// ReplySelectors 帖子页的回复表单，以及提交后用于确认回帖结果的元素
+type ReplySelectors struct {
+	Textarea string `yaml:"textarea"`
+	Submit   string `yaml:"submit"`
+	// Notice 论坛提示页中的提示文本，如发帖过快、内容太短
+	Notice string `yaml:"notice"`
+	// Post 帖子中的每一楼，Author、Content 为楼层内的作者和正文。
+	// Author 需指向只包含用户名的元素，而不是整个用户信息栏
+	Post    string `yaml:"post"`
+	Author  string `yaml:"author"`
+	Content string `yaml:"content"`
+	// LastPage 拼在帖子地址后打开最后一页，在最后一页回复，并在提交前后查找回复
+	LastPage string `yaml:"last_page"`
+}
+
+// CheckInSelectors 签到页
+type CheckInSelectors struct {
+	// Notice 签到结果或今天已签到的提示
+	Notice string `yaml:"notice"`
+	// Mood 心情单选框，会拼上 [value="..."] 选中其中一个
+	Mood   string   `yaml:"mood"`
+	Moods  []string `yaml:"moods"`
+	Submit string   `yaml:"submit"`
+}
+
+// ProfileSelectors 个人资料页的积分表格
+type ProfileSelectors struct {
+	Container string `yaml:"container"`
+	Rows      string `yaml:"rows"`
+	// Key、Value 为每行中积分名称和数值所在的单元格
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+// defaultSiteProfile 返回 2048 核基地（PHPWind）使用的选择器
+func defaultSiteProfile() SiteProfile {
+	const form = `//*[@id="main"]/form/div/table/tbody/tr/td/div`
+	return SiteProfile{
+		Header: HeaderSelectors{
+			Selector:  "div.header_up_sign",
+			LoggedIn:  "退出",
+			LoggedOut: "登录",
+		},
+		Login: LoginSelectors{
+			Form:     ".cc.p10.regItem",
+			Username: form + "/dl[1]/dd/input",
+			Password: form + "/dl[2]/dd/input",
+			Question: form + "/dl[3]/dd/select",
+			Answer:   form + "/dl[4]/dd/input",
+			Submit:   form + "/dl[7]/dd/input",
+
+			UsernameField: "pwuser",
+			PasswordField: "pwpwd",
+			QuestionField: "question",
+			AnswerField:   "answer",
+		},
+		Thread: ThreadSelectors{
+			List:      ".t.z",
+			Body:      "table#ajaxtable > tbody:nth-of-type(2)",
+			Row:       "tr.tr3.t_one",
+			Link:      "a.subject",
+			AdComment: "广告连接",
+		},
+		Reply: ReplySelectors{
+			Textarea: "#textarea",
+			Submit:   ".btn.fpbtn",
+			Notice:   "#main .f14, #main .f_one",
+			Post:     ".t5",
+			Author:   ".r_two .readName a",
+			Content:  ".tpc_content",
+			LastPage: "&page=e",
+		},
+		CheckIn: CheckInSelectors{
+			Notice: "span.f14",
+			Mood:   `input[name="qdxq"]`,
+			Moods:  []string{"kx", "ng", "ym", "wl", "nu", "ch", "fd", "yl", "shuai"},
+			Submit: "#submit_bbb",
+		},
+		Profile: ProfileSelectors{
+			Container: ".pwB_uConside_a",
+			Rows:      "table.pwB_uTable_a tr",
+			Key:       "td",
+			Value:     "th",
+		},
+	}
+}
+
+// loadSiteProfile 读取选择器文件，文件中的字段覆盖 p 中已有的值
+func loadSiteProfile(path string, p *SiteProfile) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取选择器文件 %s 失败: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return fmt.Errorf("解析选择器文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// validate 返回为空的必填选择器
+func (p *SiteProfile) validate() []error {
+	var errs []error
+	for _, field := range []struct{ name, value string }{
+		{"header.selector", p.Header.Selector},
+		{"header.logged_in", p.Header.LoggedIn},
+		{"login.form", p.Login.Form},
+		{"login.username", p.Login.Username},
+		{"login.password", p.Login.Password},
+		{"login.submit", p.Login.Submit},
+		{"thread.list", p.Thread.List},
+		{"thread.body", p.Thread.Body},
+		{"thread.row", p.Thread.Row},
+		{"thread.link", p.Thread.Link},
+		{"reply.textarea", p.Reply.Textarea},
+		{"reply.submit", p.Reply.Submit},
+		{"reply.post", p.Reply.Post},
+		{"reply.author", p.Reply.Author},
+		{"reply.content", p.Reply.Content},
+		{"checkin.notice", p.CheckIn.Notice},
+		{"checkin.submit", p.CheckIn.Submit},
+		{"profile.container", p.Profile.Container},
+		{"profile.rows", p.Profile.Rows},
+		{"profile.key", p.Profile.Key},
+		{"profile.value", p.Profile.Value},
+	} {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("selectors.%s 不能为空", field.name))
+		}
+	}
+	if p.CheckIn.Mood != "" && len(p.CheckIn.Moods) == 0 {
+		errs = append(errs, fmt.Errorf("selectors.checkin.moods 不能为空"))
+	}
+	return errs
+}
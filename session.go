@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// cookiesFile 保存登录 cookies 的文件
+const cookiesFile = "./cookies/data.json"
+
+// SessionStore 保存登录 cookies，浏览器驱动和 HTTP 驱动共用同一个文件。
+// 按每个 cookie 自身的过期时间判断是否可用，读取后驱动仍需检查页头确认登录有效
+type SessionStore struct {
+	path string
+}
+
+// NewSessionStore 创建保存到 path 的会话
+func NewSessionStore(path string) *SessionStore {
+	return &SessionStore{path: path}
+}
+
+// Path 返回 cookies 文件路径
+func (s *SessionStore) Path() string {
+	return s.path
+}
+
+// Load 读取未过期的 cookies，文件不存在或其中的 cookie 都已过期时返回空列表
+func (s *SessionStore) Load() ([]*network.Cookie, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 cookies 文件失败: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var saved []*network.Cookie
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("解析 cookies 文件失败: %w", err)
+	}
+	cookies := unexpiredCookies(saved, time.Now())
+	if expired := len(saved) - len(cookies); expired > 0 {
+		log.Printf("已忽略 %d 个过期的 cookie", expired)
+	}
+	return cookies, nil
+}
+
+// Save 只保存未过期的 cookies，以 0600 权限原子写入，避免中途失败留下损坏的文件
+func (s *SessionStore) Save(cookies []*network.Cookie) error {
+	data, err := json.Marshal(unexpiredCookies(cookies, time.Now()))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+// Clear 删除保存的 cookies，下次登录时重新提交登录表单
+func (s *SessionStore) Clear() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// unexpiredCookies 过滤掉已过期的 cookie，会话 cookie 没有过期时间，予以保留
+func unexpiredCookies(cookies []*network.Cookie, now time.Time) []*network.Cookie {
+	valid := make([]*network.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		if c == nil {
+			continue
+		}
+		if !c.Session && c.Expires > 0 && !time.UnixMilli(int64(c.Expires*1000)).After(now) {
+			continue
+		}
+		valid = append(valid, c)
+	}
+	return valid
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies", "data.json")
+	store := NewSessionStore(path)
+
+	if cookies, err := store.Load(); err != nil || len(cookies) != 0 {
+		t.Fatalf("文件不存在时 Load = %v, %v，期望空列表", cookies, err)
+	}
+
+	now := time.Now()
+	cookie := func(name string, expires time.Time, session bool) *network.Cookie {
+		c := &network.Cookie{
+			Name:         name,
+			Value:        "v",
+			Domain:       "example.com",
+			Path:         "/",
+			Expires:      -1,
+			Session:      session,
+			Priority:     network.CookiePriorityMedium,
+			SourceScheme: network.CookieSourceSchemeSecure,
+		}
+		if !session {
+			c.Expires = float64(expires.Unix())
+		}
+		return c
+	}
+	if err := store.Save([]*network.Cookie{
+		cookie("valid", now.Add(time.Hour), false),
+		cookie("expired", now.Add(-time.Hour), false),
+		cookie("session", time.Time{}, true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("cookies 文件权限 = %v，期望 0600", info.Mode().Perm())
+	}
+
+	cookies, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range cookies {
+		names = append(names, c.Name)
+	}
+	if len(names) != 2 || names[0] != "valid" || names[1] != "session" {
+		t.Errorf("Load 返回 %v，期望 [valid session]", names)
+	}
+
+	// 文件中的 cookie 之后过期，读取时同样忽略
+	if err := os.WriteFile(path, []byte(`[{"name":"old","value":"v","domain":"example.com","path":"/","expires":1,"size":4,"httpOnly":false,"secure":false,"session":false,"priority":"Medium","sourceScheme":"Secure","sourcePort":443}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cookies, err := store.Load(); err != nil || len(cookies) != 0 {
+		t.Errorf("过期的 cookies 应被忽略，Load = %v, %v", cookies, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("文件损坏时 Load 应返回错误")
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Errorf("文件不存在时 Clear 不应返回错误: %v", err)
+	}
+}
+
+func TestHTTPDriverRejectsInvalidSession(t *testing.T) {
+	forum := newFakeForum(t)
+	app, _ := newHTTPTestApp(t, forum)
+
+	// 未过期但已被论坛注销的 cookie，需要检查页头后重新登录
+	stale := &network.Cookie{
+		Name:         fakeSessionCookie,
+		Value:        "someone-else",
+		Domain:       "127.0.0.1",
+		Path:         "/",
+		Expires:      float64(time.Now().Add(24 * time.Hour).Unix()),
+		Priority:     network.CookiePriorityMedium,
+		SourceScheme: network.CookieSourceSchemeNonSecure,
+	}
+	if err := app.session.Save([]*network.Cookie{stale}); err != nil {
+		t.Fatal(err)
+	}
+
+	driver := NewHTTPDriver(app.cfg, app.session)
+	if err := driver.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if forum.Logins() != 1 {
+		t.Errorf("登录次数 = %d，cookies 无效时应提交登录表单", forum.Logins())
+	}
+	cookies, err := app.session.Load()
+	if err != nil || len(cookies) != 1 || cookies[0].Value != forum.Username {
+		t.Errorf("重新登录后保存的 cookies = %+v, %v", cookies, err)
+	}
+}
+
+func TestRunLoginKeepsCookiesOnFailure(t *testing.T) {
+	forum := newFakeForum(t)
+	app, _ := newHTTPTestApp(t, forum)
+
+	if code := runLogin(app); code != exitOK {
+		t.Fatalf("runLogin = %d，期望成功", code)
+	}
+	saved, err := app.session.Load()
+	if err != nil || len(saved) == 0 {
+		t.Fatalf("登录后没有保存 cookies: %v", err)
+	}
+
+	// 密码错误时登录失败，之前保存的 cookies 不能被删除
+	app.cfg.Account.Password = "wrong"
+	if code := runLogin(app); code != exitFailure {
+		t.Fatalf("runLogin = %d，期望失败", code)
+	}
+	cookies, err := app.session.Load()
+	if err != nil || len(cookies) != len(saved) || cookies[0].Value != saved[0].Value {
+		t.Errorf("登录失败后保存的 cookies = %+v, %v，期望保持不变", cookies, err)
+	}
+	if forum.Logins() != 1 {
+		t.Errorf("表单登录成功 %d 次，期望 1 次", forum.Logins())
+	}
+}
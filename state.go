@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultStateFile 默认状态文件路径，与 ./cookies 目录同级
+const DefaultStateFile = "./state/state.json"
+
+// StepResult 记录单个步骤的执行结果
+type StepResult struct {
+	Name       string     `json:"name"`
+	Success    bool       `json:"success"`
+	Error      string     `json:"error,omitempty"`
+	Output     StepOutput `json:"output,omitempty"`
+	FinishedAt time.Time  `json:"finished_at"`
+}
+
+// RunRecord 记录一次任务执行及其各步骤结果
+type RunRecord struct {
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	Success    bool         `json:"success"`
+	Steps      []StepResult `json:"steps"`
+}
+
+// TaskState 需要跨进程重启保留的任务状态
+type TaskState struct {
+	// Date 为状态所属日期，跨天后当天相关字段会被重置
+	Date string `json:"date"`
+	// CheckInSuccess 表示今天已签到成功，NotifySuccess 表示成功通知已送达，两者分开记录
+	CheckInSuccess  bool      `json:"check_in_success"`
+	NotifySuccess   bool      `json:"notify_success"`
+	LastRunTime     time.Time `json:"last_run_time"`
+	LastSuccessTime time.Time `json:"last_success_time"`
+	// Steps 为今天每个步骤最近一次的结果，重试时据此从失败的步骤继续
+	Steps map[string]StepResult `json:"steps"`
+	Runs  []RunRecord           `json:"runs"`
+}
+
+// LastStepError 返回今天最近一次失败的步骤，没有失败时返回 nil
+func (st TaskState) LastStepError() *StepResult {
+	for i := len(st.Runs) - 1; i >= 0; i-- {
+		steps := st.Runs[i].Steps
+		for j := len(steps) - 1; j >= 0; j-- {
+			if !steps[j].Success {
+				result := steps[j]
+				return &result
+			}
+		}
+	}
+	return nil
+}
+
+// StateStore 基于 JSON 文件的状态存储，所有写入均为原子替换
+type StateStore struct {
+	path  string
+	mu    sync.Mutex
+	state TaskState
+}
+
+// NewStateStore 打开状态文件，文件不存在时使用空状态
+func NewStateStore(path string) (*StateStore, error) {
+	s := &StateStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("读取状态文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return s, fmt.Errorf("解析状态文件失败: %w", err)
+	}
+	return s, nil
+}
+
+// Snapshot 返回当前状态的副本（已按今天日期处理跨天重置）
+func (s *StateStore) Snapshot() TaskState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.state
+	resetIfNewDay(&st, today())
+	st.Runs = append([]RunRecord(nil), st.Runs...)
+	steps := make(map[string]StepResult, len(st.Steps))
+	for name, result := range st.Steps {
+		steps[name] = result
+	}
+	st.Steps = steps
+	return st
+}
+
+// Update 在锁内修改状态并原子写回磁盘
+func (s *StateStore) Update(fn func(st *TaskState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resetIfNewDay(&s.state, today())
+	fn(&s.state)
+	return s.save()
+}
+
+// BeginRun 记录一次新的任务执行
+func (s *StateStore) BeginRun(startedAt time.Time) error {
+	return s.Update(func(st *TaskState) {
+		st.LastRunTime = startedAt
+		st.Runs = append(st.Runs, RunRecord{StartedAt: startedAt})
+	})
+}
+
+// RecordStep 记录当前执行中某个步骤的结果及其输出
+func (s *StateStore) RecordStep(name string, output StepOutput, stepErr error) {
+	err := s.Update(func(st *TaskState) {
+		if len(st.Runs) == 0 {
+			st.Runs = append(st.Runs, RunRecord{StartedAt: time.Now()})
+		}
+		result := StepResult{
+			Name:       name,
+			Success:    stepErr == nil,
+			Output:     output,
+			FinishedAt: time.Now(),
+		}
+		if stepErr != nil {
+			result.Error = stepErr.Error()
+		}
+		run := &st.Runs[len(st.Runs)-1]
+		run.Steps = append(run.Steps, result)
+
+		if st.Steps == nil {
+			st.Steps = make(map[string]StepResult)
+		}
+		st.Steps[name] = result
+	})
+	if err != nil {
+		log.Printf("保存步骤 %s 的结果失败: %v", name, err)
+	}
+}
+
+// ResetSteps 清除今天指定步骤的结果，下次执行时重新执行这些步骤
+func (s *StateStore) ResetSteps(names ...string) error {
+	return s.Update(func(st *TaskState) {
+		for _, name := range names {
+			delete(st.Steps, name)
+		}
+	})
+}
+
+// MarkCheckedIn 记录今天已签到成功
+func (s *StateStore) MarkCheckedIn() {
+	if err := s.Update(func(st *TaskState) { st.CheckInSuccess = true }); err != nil {
+		log.Printf("保存签到状态失败: %v", err)
+	}
+}
+
+// MarkNotified 记录今天的成功通知已发送
+func (s *StateStore) MarkNotified() {
+	if err := s.Update(func(st *TaskState) { st.NotifySuccess = true }); err != nil {
+		log.Printf("保存通知状态失败: %v", err)
+	}
+}
+
+// FinishRun 结束当前执行记录
+func (s *StateStore) FinishRun(success bool) {
+	err := s.Update(func(st *TaskState) {
+		if len(st.Runs) == 0 {
+			return
+		}
+		run := &st.Runs[len(st.Runs)-1]
+		run.FinishedAt = time.Now()
+		run.Success = success
+		if success {
+			st.LastSuccessTime = run.FinishedAt
+		}
+	})
+	if err != nil {
+		log.Printf("保存任务执行结果失败: %v", err)
+	}
+}
+
+// save 将状态原子写回磁盘
+func (s *StateStore) save() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0644)
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，保证目标文件不会被写坏
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
+
+// resetIfNewDay 跨天后清空当天的签到状态和执行记录
+func resetIfNewDay(st *TaskState, date string) {
+	if st.Date == date {
+		return
+	}
+	st.Date = date
+	st.CheckInSuccess = false
+	st.NotifySuccess = false
+	st.Steps = nil
+	st.Runs = nil
+}
+
+// today 返回当天日期字符串
+func today() string {
+	return time.Now().Format("2006-01-02")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "state.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(content), 0600); err != nil {
+			t.Fatalf("writeFileAtomic 返回错误: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Fatalf("文件内容 = %q, %v，期望 %q", data, err, content)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("文件权限 = %o，期望 600", perm)
+	}
+	// 临时文件重命名后不应残留
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("目录中有 %d 个文件，期望只有状态文件", len(entries))
+	}
+}
+
+func TestResetIfNewDay(t *testing.T) {
+	filled := func(date string) TaskState {
+		return TaskState{
+			Date:           date,
+			CheckInSuccess: true,
+			NotifySuccess:  true,
+			LastRunTime:    time.Date(2024, 5, 1, 0, 20, 0, 0, time.Local),
+			Steps:          map[string]StepResult{"checkin": {Name: "checkin", Success: true}},
+			Runs:           []RunRecord{{Success: true}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		state     TaskState
+		wantReset bool
+	}{
+		{name: "同一天保留状态", state: filled("2024-05-01")},
+		{name: "跨天清空当天状态", state: filled("2024-04-30"), wantReset: true},
+		{name: "没有日期的旧状态", state: filled(""), wantReset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := tt.state
+			resetIfNewDay(&st, "2024-05-01")
+			if st.Date != "2024-05-01" {
+				t.Errorf("Date = %q", st.Date)
+			}
+			reset := !st.CheckInSuccess && !st.NotifySuccess && st.Steps == nil && st.Runs == nil
+			if reset != tt.wantReset {
+				t.Errorf("重置后的状态 = %+v，期望重置 %v", st, tt.wantReset)
+			}
+			// 上次执行时间跨天也要保留，用于判断执行间隔
+			if st.LastRunTime.IsZero() {
+				t.Error("LastRunTime 不应被重置")
+			}
+		})
+	}
+}
+
+func TestStateStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := NewStateStore(path)
+	if err != nil {
+		t.Fatalf("状态文件不存在时 NewStateStore 返回错误: %v", err)
+	}
+
+	if err := store.BeginRun(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	store.RecordStep("reply", StepOutput{"content": "感谢分享"}, nil)
+	store.MarkCheckedIn()
+
+	reopened, err := NewStateStore(path)
+	if err != nil {
+		t.Fatalf("重新打开状态文件失败: %v", err)
+	}
+	st := reopened.Snapshot()
+	if !st.CheckInSuccess || !st.Steps["reply"].Success || st.Steps["reply"].Output["content"] != "感谢分享" {
+		t.Errorf("重新打开后的状态 = %+v", st)
+	}
+	if len(st.Runs) != 1 || len(st.Runs[0].Steps) != 1 {
+		t.Errorf("执行记录 = %+v，期望一次执行一个步骤", st.Runs)
+	}
+
+	if err := reopened.ResetSteps("reply"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reopened.Snapshot().Steps["reply"]; ok {
+		t.Error("ResetSteps 后步骤结果仍然存在")
+	}
+}
+
+func TestNewStateStoreCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStateStore(path)
+	if err == nil {
+		t.Error("状态文件损坏时应返回错误")
+	}
+	// 损坏时仍返回可用的空状态
+	if store == nil || store.Snapshot().CheckInSuccess {
+		t.Errorf("损坏时返回的状态 = %+v", store)
+	}
+}
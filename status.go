@@ -0,0 +1,43 @@
+package main
+
+import "time"
+
+// TaskStatus 任务的当前状态，供 Telegram 命令和 HTTP 接口使用
+type TaskStatus struct {
+	Date            string      `json:"date"`
+	CheckInSuccess  bool        `json:"check_in_success"`
+	NotifySuccess   bool        `json:"notify_success"`
+	TaskDone        bool        `json:"task_done"`
+	Running         bool        `json:"running"`
+	Paused          bool        `json:"paused"`
+	LastRunTime     time.Time   `json:"last_run_time"`
+	LastSuccessTime time.Time   `json:"last_success_time"`
+	NextRunTime     time.Time   `json:"next_run_time"`
+	RetryAt         time.Time   `json:"retry_at"`
+	LastStepError   *StepResult `json:"last_step_error"`
+}
+
+// currentStatus 汇总持久化状态和运行时状态
+func (a *App) currentStatus() TaskStatus {
+	state := a.state.Snapshot()
+
+	a.mu.Lock()
+	running := a.running
+	paused := a.paused
+	pendingRetry := a.retryAt
+	a.mu.Unlock()
+
+	return TaskStatus{
+		Date:            state.Date,
+		CheckInSuccess:  state.CheckInSuccess,
+		NotifySuccess:   state.NotifySuccess,
+		TaskDone:        a.pipeline.Done(state),
+		Running:         running,
+		Paused:          paused,
+		LastRunTime:     state.LastRunTime,
+		LastSuccessTime: state.LastSuccessTime,
+		NextRunTime:     a.nextScheduledRun(),
+		RetryAt:         pendingRetry,
+		LastStepError:   state.LastStepError(),
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// newTaskPipeline 构建签到任务的步骤流水线
+func newTaskPipeline() *Pipeline {
+	return &Pipeline{Steps: []Step{
+		{
+			Name:         "navigate",
+			Title:        "打开论坛",
+			Session:      true,
+			NeedsBrowser: true,
+			Retry:        RetryPolicy{Attempts: 3, Delay: 10 * time.Second},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				driver, err := tc.Driver()
+				if err != nil {
+					return nil, err
+				}
+				return nil, driver.Open()
+			},
+		},
+		{
+			Name:         "login",
+			Title:        "登录",
+			Session:      true,
+			NeedsBrowser: true,
+			Retry:        RetryPolicy{Attempts: 3, Delay: 10 * time.Second},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				driver, err := tc.Driver()
+				if err != nil {
+					return nil, err
+				}
+				return nil, driver.Login()
+			},
+		},
+		{
+			Name:         "pick_post",
+			Title:        "提取帖子",
+			NeedsBrowser: true,
+			Retry:        RetryPolicy{Attempts: 2, Delay: 5 * time.Second},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				driver, err := tc.Driver()
+				if err != nil {
+					return nil, err
+				}
+				title, href, err := driver.PickThread()
+				if err != nil {
+					return nil, err
+				}
+				return StepOutput{"title": title, "href": href}, nil
+			},
+		},
+		{
+			// 回帖不是幂等操作，只尝试一次，避免重复回复
+			Name:         "reply",
+			Title:        "回帖",
+			NeedsBrowser: true,
+			Retry:        RetryPolicy{Attempts: 1},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				driver, err := tc.Driver()
+				if err != nil {
+					return nil, err
+				}
+				result, err := driver.Reply(tc.Output("pick_post", "href"))
+				if err != nil {
+					return nil, err
+				}
+				output := StepOutput{"content": result.Content, "message": result.Message}
+				if !result.Posted {
+					// 无法确认时不重试，避免重复回帖，通知中提醒手动检查
+					output["unverified"] = "true"
+					log.Printf("已提交回帖但未能确认结果: \n标题：%s, \n回帖：%s", tc.Output("pick_post", "title"), result.Content)
+					return output, nil
+				}
+				log.Printf("成功回复帖子: \n标题：%s, \n回帖：%s", tc.Output("pick_post", "title"), result.Content)
+				return output, nil
+			},
+		},
+		{
+			Name:         "checkin",
+			Title:        "签到",
+			NeedsBrowser: true,
+			Retry:        RetryPolicy{Attempts: 2, Delay: 10 * time.Second},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				driver, err := tc.Driver()
+				if err != nil {
+					return nil, err
+				}
+				result, err := driver.CheckIn()
+				if err != nil {
+					return nil, err
+				}
+				output, err := checkInOutcome(result)
+				if err != nil {
+					return output, err
+				}
+				// 签到成功或今天已签到时立即落盘，之后的步骤失败也不会再重复签到
+				tc.app.state.MarkCheckedIn()
+				return output, nil
+			},
+		},
+		{
+			Name:         "userinfo",
+			Title:        "获取用户信息",
+			NeedsBrowser: true,
+			Retry:        RetryPolicy{Attempts: 3, Delay: 5 * time.Second},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				driver, err := tc.Driver()
+				if err != nil {
+					return nil, err
+				}
+				points, err := driver.FetchProfile()
+				if err != nil {
+					return nil, err
+				}
+				// 记录积分历史，用于生成周报和月报
+				if len(points.Values) > 0 {
+					tc.app.metrics.SetUserPoints(points)
+					if err := tc.app.history.Append(time.Now(), points); err != nil {
+						log.Printf("保存积分历史失败: %v", err)
+					}
+				}
+				return StepOutput{"info": points.String()}, nil
+			},
+		},
+		{
+			Name:  "notify",
+			Title: "发送通知",
+			Retry: RetryPolicy{Attempts: 3, Delay: 10 * time.Second},
+			Run: func(tc *TaskContext) (StepOutput, error) {
+				replyTitle := "成功回复帖子"
+				if tc.Output("reply", "unverified") != "" {
+					replyTitle = "已提交回帖，但未能在帖子中确认，请手动检查"
+				}
+				replyInfo := fmt.Sprintf("%s: \n标题：%s, \n回帖：%s",
+					replyTitle, tc.Output("pick_post", "title"), tc.Output("reply", "content"))
+				if message := tc.Output("reply", "message"); message != "" {
+					replyInfo += "\n论坛提示：" + message
+				}
+				notificationMsg := fmt.Sprintf(
+					"✅ hjd2048 ✅，\n时间: %s\n%s\n%s\n%s",
+					time.Now().Format("2006-01-02 15:04:05"),
+					replyInfo,
+					tc.Output("checkin", "summary")+"\n"+tc.Output("checkin", "result"),
+					tc.Output("userinfo", "info"),
+				)
+				err := tc.app.notifier.Notify(Message{
+					Kind:  MessageSuccess,
+					Title: "hjd2048 签到成功",
+					Text:  notificationMsg,
+				})
+				// 已保存待补发的消息不算失败，否则重试会导致重复通知
+				if err != nil && !errors.Is(err, ErrNotificationDeferred) {
+					return nil, err
+				}
+				tc.app.state.MarkNotified()
+				return nil, nil
+			},
+		},
+	}}
+}
+
+// minRunInterval 定时执行时与上次执行的最小间隔，避免启动时执行与定时任务重复运行
+const minRunInterval = 5 * time.Minute
+
+var (
+	// ErrTaskRunning 任务正在执行，不能再次开始
+	ErrTaskRunning = errors.New("任务已在运行中")
+	// ErrTaskDone 今天的任务已全部完成，不需要再执行
+	ErrTaskDone = errors.New("今天的任务已全部完成")
+)
+
+// beginTask 检查任务能否开始并标记为运行中，不能开始时返回原因。
+// scheduled 为 true 时还要求距离上次执行不少于 minRunInterval
+func (a *App) beginTask(scheduled bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running {
+		return ErrTaskRunning
+	}
+
+	state := a.state.Snapshot()
+	if scheduled && !state.LastRunTime.IsZero() {
+		if since := time.Since(state.LastRunTime); since < minRunInterval {
+			return fmt.Errorf("距离上次执行仅 %v，小于%v", since.Round(time.Second), minRunInterval)
+		}
+	}
+	// 如果今天所有步骤都已完成，不执行任务
+	if a.pipeline.Done(state) {
+		return ErrTaskDone
+	}
+
+	a.running = true
+	if err := a.state.BeginRun(time.Now()); err != nil {
+		log.Printf("保存任务状态失败: %v", err)
+	}
+	return nil
+}
+
+// executeTask 定时执行任务流水线，不满足执行条件时跳过
+func (a *App) executeTask() {
+	if err := a.beginTask(true); err != nil {
+		log.Printf("%v，跳过本次执行", err)
+		return
+	}
+	a.runTask()
+}
+
+// triggerTask 手动触发任务，不检查最小执行间隔，任务在后台执行。不能执行时返回原因
+func (a *App) triggerTask() error {
+	if err := a.beginTask(false); err != nil {
+		return err
+	}
+	go a.runTask()
+	return nil
+}
+
+// runTask 执行已标记为运行中的任务，失败时安排重试，重试会从失败的步骤继续
+func (a *App) runTask() {
+	// 函数结束时清理状态
+	defer func() {
+		a.mu.Lock()
+		a.running = false
+		a.mu.Unlock()
+	}()
+
+	if err := a.runPipeline(); err != nil {
+		a.scheduleRetry(err)
+	}
+}
+
+// runPipeline 执行一次任务流水线并记录结果，失败时只返回错误，不安排重试
+func (a *App) runPipeline() error {
+	log.Println("开始执行任务...")
+	a.metrics.RunStarted()
+
+	// 确保无论如何浏览器都会被关闭
+	tc := &TaskContext{app: a}
+	defer tc.Close()
+
+	if err := a.pipeline.Run(tc); err != nil {
+		log.Printf("任务失败: %v", err)
+		if errors.Is(err, ErrSessionExpired) {
+			a.invalidateSession()
+		}
+		if errors.Is(err, ErrReplyRequired) {
+			a.resetReply()
+		}
+		var stepErr *StepError
+		if errors.As(err, &stepErr) {
+			a.metrics.RunFailed(stepErr.Step.Name)
+		}
+		a.state.FinishRun(false)
+		return err
+	}
+
+	// 任务成功，更新上次成功时间
+	a.state.FinishRun(true)
+	a.metrics.RunSucceeded()
+	log.Println("任务完成")
+	return nil
+}
+
+// invalidateSession 删除已保存的 cookies，下次执行时重新提交登录表单
+func (a *App) invalidateSession() {
+	if err := a.session.Clear(); err != nil {
+		log.Printf("删除失效的 cookies 失败: %v", err)
+		return
+	}
+	log.Println("登录已失效，已删除 cookies，下次执行时重新登录")
+}
+
+// resetReply 清除今天提取帖子和回帖的结果，重试时重新回帖后再签到
+func (a *App) resetReply() {
+	if err := a.state.ResetSteps("pick_post", "reply"); err != nil {
+		log.Printf("保存任务状态失败: %v", err)
+		return
+	}
+	log.Println("论坛要求先回帖，重试时将重新回帖")
+}
+
+// openSession 创建论坛驱动并确保已登录
+func (a *App) openSession() (SiteDriver, error) {
+	driver, err := a.newDriver()
+	if err != nil {
+		return nil, fmt.Errorf("创建浏览器失败: %w", err)
+	}
+	if err := driver.Login(); err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("登录失败: %w", err)
+	}
+	return driver, nil
+}
+
+// fetchUserInfo 单独登录并获取用户积分信息，不回帖也不签到
+func (a *App) fetchUserInfo() (string, error) {
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		return "", errors.New("任务正在运行中，请稍后再试")
+	}
+	a.running = true
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.running = false
+		a.mu.Unlock()
+	}()
+
+	driver, err := a.openSession()
+	if err != nil {
+		return "", err
+	}
+	defer driver.Close()
+
+	points, err := driver.FetchProfile()
+	if err != nil {
+		return "", err
+	}
+	a.metrics.SetUserPoints(points)
+	return points.String(), nil
+}
+
+// todayTaskDone 根据持久化状态判断今天的任务是否已全部完成
+func (a *App) todayTaskDone() bool {
+	return a.pipeline.Done(a.state.Snapshot())
+}
+
+// scheduleRetry 安排任务重试
+func (a *App) scheduleRetry(cause error) {
+	reason := cause.Error()
+	// 如果今天的任务已经完成，不安排重试
+	if a.todayTaskDone() {
+		log.Printf("今天的任务已经完成，不重试: %s", reason)
+		return
+	}
+
+	interval := time.Duration(a.cfg.Schedule.RetryInterval)
+	log.Printf("任务失败，原因: %s，将在 %v 后重试", reason, interval)
+
+	// 取消之前的重试计时器（如果存在）
+	a.mu.Lock()
+	if a.retryTimer != nil {
+		a.retryTimer.Stop()
+	}
+
+	// 设置新的重试计时器
+	a.retryAt = time.Now().Add(interval)
+	a.retryTimer = time.AfterFunc(interval, func() {
+		a.mu.Lock()
+		a.retryAt = time.Time{}
+		a.mu.Unlock()
+
+		// 重试前再次检查是否已完成
+		if a.todayTaskDone() {
+			log.Println("定时重试前检测到今天的任务已经完成，取消重试")
+			return
+		}
+
+		// 重试由上次失败安排，不受最小执行间隔限制
+		if err := a.beginTask(false); err != nil {
+			log.Printf("%v，取消重试", err)
+			return
+		}
+		log.Println("开始重试任务...")
+		a.runTask()
+	})
+	a.mu.Unlock()
+
+	a.notifyFailure(cause, interval)
+}
+
+// notifyFailure 发送任务失败通知，retryIn 为 0 表示不会自动重试。
+// 失败步骤保存了页面快照时附上截图
+func (a *App) notifyFailure(cause error, retryIn time.Duration) {
+	failureMsg := fmt.Sprintf(
+		"❌ 任务失败 ❌\n时间: %s\n原因: %s",
+		time.Now().Format("2006-01-02 15:04:05"),
+		cause,
+	)
+	msg := Message{
+		Kind:  MessageFailure,
+		Title: "hjd2048 任务失败",
+	}
+	var stepErr *StepError
+	if errors.As(cause, &stepErr) && stepErr.Snapshot != nil {
+		failureMsg += fmt.Sprintf("\n页面: %s\n快照: %s", stepErr.Snapshot.URL, stepErr.Snapshot.Dir)
+		msg.Image = stepErr.Snapshot.Screenshot
+	}
+	if retryIn > 0 {
+		failureMsg += fmt.Sprintf("\n将在 %d 分钟后从失败的步骤继续重试", int(retryIn.Minutes()))
+	}
+	msg.Text = failureMsg
+
+	if err := a.notifier.Notify(msg); err != nil {
+		log.Printf("发送失败通知失败: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTriggerTaskSkipsRunInterval(t *testing.T) {
+	forum := newFakeForum(t)
+	forum.SetReplyNotice("发帖间隔不能少于 30 秒")
+	app, _ := newHTTPTestApp(t, forum)
+
+	app.executeTask()
+	forum.SetReplyNotice("")
+	// 定时执行距离上次不足最小间隔，直接跳过
+	app.executeTask()
+	if len(forum.Replies()) != 0 {
+		t.Fatalf("定时执行未被跳过，回帖 %v", forum.Replies())
+	}
+
+	// 手动触发不受最小间隔限制
+	if err := app.triggerTask(); err != nil {
+		t.Fatalf("triggerTask 返回错误: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !app.todayTaskDone() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !app.todayTaskDone() {
+		t.Fatal("手动触发后任务未完成")
+	}
+	for app.currentStatus().Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := app.triggerTask(); !errors.Is(err, ErrTaskDone) {
+		t.Errorf("任务完成后 triggerTask 返回 %v，期望 ErrTaskDone", err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DefaultTelegramOutboxFile 发送失败的消息保存位置
+const DefaultTelegramOutboxFile = "./state/telegram_outbox.json"
+
+// Telegram 发送重试参数
+const (
+	telegramSendAttempts = 5
+	telegramBaseBackoff  = 2 * time.Second
+	telegramMaxBackoff   = time.Minute
+)
+
+// outboxEntry 待重发的消息
+type outboxEntry struct {
+	ChatID    int64     `json:"chat_id"`
+	ThreadID  int       `json:"thread_id,omitempty"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// telegramRequest 发送队列中的一条消息，photo 不为空时发送图片，text 作为图片说明
+type telegramRequest struct {
+	target ChatTarget
+	text   string
+	photo  string
+	done   chan error
+}
+
+// TelegramClient 长期复用的 Bot 客户端，所有消息经由单个队列串行发送，
+// 失败时指数退避重试，最终失败的消息保存到磁盘，在下一次发送成功后补发
+type TelegramClient struct {
+	token      string
+	outboxPath string
+
+	botMu sync.Mutex
+	bot   *tgbotapi.BotAPI
+
+	queueMu sync.Mutex
+	queue   chan telegramRequest
+	closed  bool
+	stopped chan struct{}
+}
+
+// NewTelegramClient 创建客户端并启动发送队列
+func NewTelegramClient(token, outboxPath string) *TelegramClient {
+	c := &TelegramClient{
+		token:      token,
+		outboxPath: outboxPath,
+		queue:      make(chan telegramRequest, 32),
+		stopped:    make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Bot 返回共享的 Bot 实例，首次调用时才会请求 getMe，失败后下次调用会重新尝试
+func (c *TelegramClient) Bot() (*tgbotapi.BotAPI, error) {
+	c.botMu.Lock()
+	defer c.botMu.Unlock()
+
+	if c.bot != nil {
+		return c.bot, nil
+	}
+	bot, err := tgbotapi.NewBotAPI(c.token)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Telegram Bot 实例失败: %w", err)
+	}
+	bot.Debug = false
+	c.bot = bot
+	return bot, nil
+}
+
+// Send 将消息放入发送队列并等待结果。
+// 重试耗尽后消息会保存到磁盘，此时返回的错误包装了 ErrNotificationDeferred
+func (c *TelegramClient) Send(target ChatTarget, text string) error {
+	return c.enqueue(telegramRequest{target: target, text: text})
+}
+
+// SendPhoto 发送本地图片，失败时只返回错误，不保存待补发
+func (c *TelegramClient) SendPhoto(target ChatTarget, path, caption string) error {
+	return c.enqueue(telegramRequest{target: target, text: caption, photo: path})
+}
+
+// enqueue 将请求放入发送队列并等待结果
+func (c *TelegramClient) enqueue(req telegramRequest) error {
+	c.queueMu.Lock()
+	if c.closed {
+		c.queueMu.Unlock()
+		return errors.New("Telegram 客户端已关闭")
+	}
+	req.done = make(chan error, 1)
+	c.queue <- req
+	c.queueMu.Unlock()
+
+	return <-req.done
+}
+
+// Close 停止接收新消息，并等待队列中的消息发送完毕
+func (c *TelegramClient) Close() {
+	c.queueMu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.queue)
+	}
+	c.queueMu.Unlock()
+	<-c.stopped
+}
+
+// run 串行处理发送队列
+func (c *TelegramClient) run() {
+	defer close(c.stopped)
+	for req := range c.queue {
+		err := c.sendWithBackoff(req)
+		switch {
+		case err == nil:
+			c.flushOutbox()
+		case isPermanentTelegramError(err), req.photo != "":
+			// 参数错误等无法通过重试解决的错误不保存，避免每次都补发失败；图片也不保存
+		default:
+			if saveErr := c.saveToOutbox(req.target, req.text); saveErr != nil {
+				log.Printf("保存待重发的 Telegram 消息失败: %v", saveErr)
+			} else {
+				err = fmt.Errorf("%w: %v", ErrNotificationDeferred, err)
+			}
+		}
+		req.done <- err
+	}
+}
+
+// sendWithBackoff 发送消息，失败时指数退避，遇到 429 时按 retry_after 等待
+func (c *TelegramClient) sendWithBackoff(req telegramRequest) error {
+	target := req.target
+	backoff := telegramBaseBackoff
+	var lastErr error
+	for attempt := 1; attempt <= telegramSendAttempts; attempt++ {
+		bot, err := c.Bot()
+		if err == nil {
+			if req.photo != "" {
+				err = sendTelegramPhoto(bot, target, req.photo, req.text)
+			} else {
+				err = sendTelegramText(bot, target, req.text)
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if isPermanentTelegramError(err) || attempt == telegramSendAttempts {
+			break
+		}
+
+		wait := backoff
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait = time.Duration(apiErr.RetryAfter) * time.Second
+		}
+		log.Printf("发送 Telegram 消息到 %s 失败(%d/%d): %v，%v 后重试", target, attempt, telegramSendAttempts, err, wait)
+		time.Sleep(wait)
+
+		backoff *= 2
+		if backoff > telegramMaxBackoff {
+			backoff = telegramMaxBackoff
+		}
+	}
+	return lastErr
+}
+
+// isPermanentTelegramError 判断是否为重试也无法成功的错误（如 chat 不存在、Bot 被踢出）
+func isPermanentTelegramError(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == 400 || apiErr.Code == 401 || apiErr.Code == 403
+}
+
+// loadOutbox 读取待重发的消息
+func (c *TelegramClient) loadOutbox() ([]outboxEntry, error) {
+	data, err := os.ReadFile(c.outboxPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []outboxEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// writeOutbox 写回待重发的消息，列表为空时删除文件
+func (c *TelegramClient) writeOutbox(entries []outboxEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(c.outboxPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.outboxPath, data, 0600)
+}
+
+// saveToOutbox 保存一条发送失败的消息
+func (c *TelegramClient) saveToOutbox(target ChatTarget, text string) error {
+	entries, err := c.loadOutbox()
+	if err != nil {
+		log.Printf("读取待重发消息失败，将覆盖: %v", err)
+	}
+	entries = append(entries, outboxEntry{
+		ChatID:    target.ChatID,
+		ThreadID:  target.ThreadID,
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+	log.Printf("Telegram 消息发送失败，已保存待重发 (共 %d 条)", len(entries))
+	return c.writeOutbox(entries)
+}
+
+// flushOutbox 在发送成功后补发之前失败的消息，补发失败的继续保留
+func (c *TelegramClient) flushOutbox() {
+	entries, err := c.loadOutbox()
+	if err != nil {
+		log.Printf("读取待重发消息失败: %v", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	bot, err := c.Bot()
+	if err != nil {
+		return
+	}
+
+	var remaining []outboxEntry
+	for _, entry := range entries {
+		target := ChatTarget{ChatID: entry.ChatID, ThreadID: entry.ThreadID}
+		text := fmt.Sprintf("[补发 %s]\n%s", entry.CreatedAt.Format("2006-01-02 15:04:05"), entry.Text)
+		if err := sendTelegramText(bot, target, text); err != nil && !isPermanentTelegramError(err) {
+			remaining = append(remaining, entry)
+		}
+	}
+	log.Printf("已补发 %d 条 Telegram 消息，剩余 %d 条", len(entries)-len(remaining), len(remaining))
+
+	if err := c.writeOutbox(remaining); err != nil {
+		log.Printf("保存待重发消息失败: %v", err)
+	}
+}
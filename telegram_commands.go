@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// telegramCommandHelp 命令帮助信息
+const telegramCommandHelp = `可用命令:
+/status - 查看任务状态
+/run - 立即执行任务
+/pause - 暂停定时任务
+/resume - 恢复定时任务
+/points - 查询积分
+/logs - 查看今天的最新日志`
+
+// listenTelegramCommands 长轮询 Telegram 更新并处理来自已配置会话的命令
+func (a *App) listenTelegramCommands() {
+	if a.telegram == nil {
+		log.Println("未配置 TELEGRAM_BOT_TOKEN，无法监听 Telegram 命令")
+		return
+	}
+	bot, err := a.telegram.Bot()
+	if err != nil {
+		log.Printf("创建 Telegram 命令 Bot 失败: %v", err)
+		return
+	}
+
+	a.commandBotMu.Lock()
+	a.commandBot = bot
+	a.commandBotMu.Unlock()
+
+	allowed := make(map[int64]bool)
+	for _, targets := range [][]ChatTarget{a.cfg.chatTargets, a.cfg.successChatTargets, a.cfg.failureChatTargets} {
+		for _, target := range targets {
+			allowed[target.ChatID] = true
+		}
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	u.AllowedUpdates = []string{"message"}
+
+	log.Printf("开始监听 Telegram 命令 (@%s)", bot.Self.UserName)
+	for update := range bot.GetUpdatesChan(u) {
+		msg := update.Message
+		if msg == nil || !msg.IsCommand() {
+			continue
+		}
+		if !allowed[msg.Chat.ID] {
+			log.Printf("忽略来自未授权会话 %d 的命令 /%s", msg.Chat.ID, msg.Command())
+			continue
+		}
+
+		log.Printf("收到 Telegram 命令 /%s (会话 %d)", msg.Command(), msg.Chat.ID)
+		go func(msg *tgbotapi.Message) {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, a.handleTelegramCommand(msg.Command()))
+			reply.ReplyToMessageID = msg.MessageID
+			if _, err := bot.Send(reply); err != nil {
+				log.Printf("回复 Telegram 命令失败: %v", err)
+			}
+		}(msg)
+	}
+	log.Println("Telegram 命令监听已停止")
+}
+
+// stopTelegramCommands 停止命令长轮询
+func (a *App) stopTelegramCommands() {
+	a.commandBotMu.Lock()
+	defer a.commandBotMu.Unlock()
+	if a.commandBot != nil {
+		a.commandBot.StopReceivingUpdates()
+		a.commandBot = nil
+	}
+}
+
+// handleTelegramCommand 执行命令并返回回复内容
+func (a *App) handleTelegramCommand(command string) string {
+	switch command {
+	case "status":
+		return a.statusText()
+	case "run":
+		if err := a.triggerTask(); err != nil {
+			return "未执行任务: " + err.Error()
+		}
+		return "已触发任务执行，结果将通过通知发送"
+	case "pause":
+		a.pauseScheduler()
+		return "定时任务已暂停"
+	case "resume":
+		a.resumeScheduler()
+		return "定时任务已恢复，下次执行: " + formatTime(a.nextScheduledRun())
+	case "points":
+		info, err := a.fetchUserInfo()
+		if err != nil {
+			return "获取积分失败: " + err.Error()
+		}
+		return info
+	case "logs":
+		text, err := tailLogFile(30)
+		if err != nil {
+			return "读取日志失败: " + err.Error()
+		}
+		return text
+	default:
+		return telegramCommandHelp
+	}
+}
+
+// statusText 汇总当前任务状态
+func (a *App) statusText() string {
+	status := a.currentStatus()
+
+	var sb strings.Builder
+	sb.WriteString("📋 任务状态\n")
+	sb.WriteString(fmt.Sprintf("今日签到: %s\n", yesNo(status.CheckInSuccess)))
+	sb.WriteString(fmt.Sprintf("今日任务完成: %s\n", yesNo(status.TaskDone)))
+	sb.WriteString(fmt.Sprintf("正在运行: %s\n", yesNo(status.Running)))
+	sb.WriteString(fmt.Sprintf("定时任务已暂停: %s\n", yesNo(status.Paused)))
+	sb.WriteString(fmt.Sprintf("上次执行: %s\n", formatTime(status.LastRunTime)))
+	sb.WriteString(fmt.Sprintf("上次成功: %s\n", formatTime(status.LastSuccessTime)))
+	sb.WriteString(fmt.Sprintf("下次定时执行: %s\n", formatTime(status.NextRunTime)))
+	sb.WriteString(fmt.Sprintf("待执行重试: %s", formatTime(status.RetryAt)))
+	if status.LastStepError != nil {
+		sb.WriteString(fmt.Sprintf("\n最近失败: %s (%s)", status.LastStepError.Name, status.LastStepError.Error))
+	}
+	return sb.String()
+}
+
+// tailLogFile 读取今天日志文件的最后 n 行，并截断到 Telegram 消息长度限制以内
+func tailLogFile(n int) (string, error) {
+	if currentLogFile == nil {
+		return "", errors.New("日志文件未打开")
+	}
+	file, err := os.Open(currentLogFile.Name())
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "今天还没有日志", nil
+	}
+
+	text := strings.Join(lines, "\n")
+	const maxLen = 4000
+	if len(text) > maxLen {
+		text = text[len(text)-maxLen:]
+		// 从下一行开始，避免截断半个 UTF-8 字符
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	return text, nil
+}
+
+// formatTime 格式化时间，零值显示为“无”
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "无"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// yesNo 将布尔值转为中文
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SelectorCheck 单个选择器的检查结果
+type SelectorCheck struct {
+	// Name 选择器在配置中的名称，如 reply.textarea
+	Name     string
+	Selector string
+	Found    bool
+	Visible  bool
+	// Optional 为 true 时找不到不算失败
+	Optional bool
+}
+
+// OK 选择器是否存在且可见
+func (c SelectorCheck) OK() bool {
+	return c.Optional || (c.Found && c.Visible)
+}
+
+// PageReport 一个页面的检查结果
+type PageReport struct {
+	Page   string
+	URL    string
+	Checks []SelectorCheck
+	// Note 补充说明，如今天已签到时签到按钮不会出现
+	Note string
+	Err  error
+}
+
+// OK 页面能否打开且所有必需的选择器都可用
+func (r PageReport) OK() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, c := range r.Checks {
+		if !c.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyPassed 所有页面是否都检查通过
+func verifyPassed(reports []PageReport) bool {
+	for _, r := range reports {
+		if !r.OK() {
+			return false
+		}
+	}
+	return len(reports) > 0
+}
+
+// formatVerifyReport 按页面输出检查报告
+func formatVerifyReport(reports []PageReport) string {
+	var sb strings.Builder
+	for _, r := range reports {
+		status := "通过"
+		if !r.OK() {
+			status = "失败"
+		}
+		fmt.Fprintf(&sb, "[%s] %s", status, r.Page)
+		if r.URL != "" {
+			fmt.Fprintf(&sb, " %s", r.URL)
+		}
+		sb.WriteString("\n")
+		for _, c := range r.Checks {
+			mark := "✓"
+			detail := ""
+			switch {
+			case !c.Found:
+				detail = "未找到"
+			case !c.Visible:
+				detail = "不可见"
+			}
+			if detail != "" {
+				mark = "✗"
+				if c.Optional {
+					mark = "-"
+					detail += "（可选）"
+				}
+			}
+			fmt.Fprintf(&sb, "  %s %-18s %s", mark, c.Name, c.Selector)
+			if detail != "" {
+				fmt.Fprintf(&sb, "  %s", detail)
+			}
+			sb.WriteString("\n")
+		}
+		if r.Note != "" {
+			fmt.Fprintf(&sb, "  说明: %s\n", r.Note)
+		}
+		if r.Err != nil {
+			fmt.Fprintf(&sb, "  错误: %v\n", r.Err)
+		}
+	}
+	if verifyPassed(reports) {
+		sb.WriteString("所有页面检查通过\n")
+	} else {
+		sb.WriteString("部分页面检查失败，请根据上面的结果修改选择器配置\n")
+	}
+	return sb.String()
+}